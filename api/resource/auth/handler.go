@@ -0,0 +1,664 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"fitapp-backend/api/resource/common/caller"
+	"fitapp-backend/api/resource/common/token"
+	"fitapp-backend/api/resource/common/totp"
+	"fitapp-backend/api/resource/user"
+)
+
+// API holds the dependencies for the authentication handlers
+type API struct {
+	repository     *Repository
+	userRepository *user.Repository
+	tokens         *token.Manager
+	validate       *validator.Validate
+}
+
+// New creates a new API instance for authentication routes
+func New(db *gorm.DB, tokens *token.Manager) *API {
+	return &API{
+		repository:     NewRepository(db),
+		userRepository: user.NewRepository(db),
+		tokens:         tokens,
+		validate:       validator.New(),
+	}
+}
+
+// SessionActive reports whether the login session is still active. It is the
+// caller.SessionChecker of the access token middleware.
+func (a *API) SessionActive(sessionID uuid.UUID) (bool, error) {
+	return a.repository.SessionActive(sessionID, time.Now())
+}
+
+func enableCors(w *http.ResponseWriter) {
+	(*w).Header().Set("Access-Control-Allow-Origin", "*")
+}
+
+func handleErr(w http.ResponseWriter, status int, message string, details interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	response := map[string]interface{}{"error": message}
+	if details != nil {
+		response["details"] = details
+	}
+	json.NewEncoder(w).Encode(response)
+	// Log the error as well
+	fmt.Printf("ERROR [%d]: %s - Details: %v\n", status, message, details)
+}
+
+// formatValidationErrors maps each failed field to the validation tag it failed
+func formatValidationErrors(err error) map[string]string {
+	errors := make(map[string]string)
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrors {
+			errors[fieldErr.Field()] = fmt.Sprintf("failed validation on '%s'", fieldErr.Tag())
+		}
+	}
+	return errors
+}
+
+// Register godoc
+//
+//	@summary		Register
+//	@description	Create an account with a password and return the user with the tokens of a new session
+//	@tags			auth
+//	@accept			json
+//	@produce		json
+//	@param			body	body		RegisterForm	true	"Registration form"
+//	@success		201	{object}	RegisterDTO "Returns the created user and a token pair"
+//	@failure		400	{object}	map[string]interface{} "Bad Request (Invalid JSON or Validation Errors)"
+//	@failure		409	{object}	map[string]string "Username already taken"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/auth/register [post]
+func (a *API) Register(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	form := &RegisterForm{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid request body JSON", err.Error())
+		return
+	}
+
+	if err := a.validate.Struct(form); err != nil {
+		handleErr(w, http.StatusBadRequest, "Validation failed", formatValidationErrors(err))
+		return
+	}
+	if form.Sex == nil {
+		handleErr(w, http.StatusBadRequest, "Validation failed", map[string]string{"Sex": "field is required"})
+		return
+	}
+	if form.Weight == 0 {
+		handleErr(w, http.StatusBadRequest, "Validation failed", map[string]string{"Weight": "field is required"})
+		return
+	}
+
+	newUser, err := form.ToModel(uuid.New())
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Validation failed", map[string]string{"BirthDate": err.Error()})
+		return
+	}
+	if err := newUser.SetPassword(form.Password); err != nil {
+		handleErr(w, http.StatusBadRequest, "Validation failed", map[string]string{"Password": err.Error()})
+		return
+	}
+
+	if _, err := a.userRepository.ReadByUsername(newUser.Username); err == nil {
+		handleErr(w, http.StatusConflict, "Username already taken", nil)
+		return
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		handleErr(w, http.StatusInternalServerError, "Failed to check username", err.Error())
+		return
+	}
+
+	createdUser, err := a.userRepository.Create(newUser)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to create user", err.Error())
+		return
+	}
+
+	tokenDTO, err := a.startSession(r, createdUser, form.Device)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to start session", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(&RegisterDTO{User: createdUser.ToDto(), TokenDTO: tokenDTO}); err != nil {
+		fmt.Printf("Error encoding registration response: %v\n", err)
+	}
+}
+
+// Login godoc
+//
+//	@summary		Login
+//	@description	Exchange a username and password for the tokens of a new session on the device.
+//	@description	Accounts with two-factor authentication also need a TOTP or recovery code; without
+//	@description	one the request fails with details "two_factor_required".
+//	@tags			auth
+//	@accept			json
+//	@produce		json
+//	@param			body	body		LoginForm	true	"Credentials"
+//	@success		200	{object}	TokenDTO
+//	@failure		400	{object}	map[string]interface{} "Bad Request (Invalid JSON or Validation Errors)"
+//	@failure		401	{object}	map[string]string "Invalid username, password or two-factor code"
+//	@failure		429	{object}	map[string]string "Too many invalid two-factor codes, see Retry-After"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/auth/login [post]
+func (a *API) Login(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	form := &LoginForm{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid request body JSON", err.Error())
+		return
+	}
+	if err := a.validate.Struct(form); err != nil {
+		handleErr(w, http.StatusBadRequest, "Validation failed", formatValidationErrors(err))
+		return
+	}
+
+	u, err := a.userRepository.ReadByUsername(form.Username)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			handleErr(w, http.StatusInternalServerError, "Failed to read user", err.Error())
+			return
+		}
+		u = &user.User{PasswordHash: dummyPasswordHash} // Costs the same as checking a real user
+	}
+	if !u.CheckPassword(form.Password) || u.ID == uuid.Nil {
+		handleErr(w, http.StatusUnauthorized, "Invalid username or password", nil)
+		return
+	}
+	if !a.checkSecondFactor(w, u.ID, form.Code) {
+		return
+	}
+
+	tokenDTO, err := a.startSession(r, u, form.Device)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to start session", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(tokenDTO); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode response", err.Error())
+		return
+	}
+}
+
+// Refresh godoc
+//
+//	@summary		Refresh tokens
+//	@description	Exchange a refresh token for a new token pair. Every refresh token works once;
+//	@description	presenting a used one revokes its whole session, as the token has likely leaked.
+//	@tags			auth
+//	@accept			json
+//	@produce		json
+//	@param			body	body		RefreshForm	true	"Refresh token"
+//	@success		200	{object}	TokenDTO
+//	@failure		400	{object}	map[string]interface{} "Bad Request (Invalid JSON or Validation Errors)"
+//	@failure		401	{object}	map[string]string "Invalid, expired, revoked or reused refresh token"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/auth/refresh [post]
+func (a *API) Refresh(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	form := &RefreshForm{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid request body JSON", err.Error())
+		return
+	}
+	if err := a.validate.Struct(form); err != nil {
+		handleErr(w, http.StatusBadRequest, "Validation failed", formatValidationErrors(err))
+		return
+	}
+
+	now := time.Now()
+	used, err := a.repository.FindRefreshToken(token.HashRefreshToken(form.RefreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			handleErr(w, http.StatusUnauthorized, "Invalid refresh token", nil)
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to read refresh token", err.Error())
+		}
+		return
+	}
+	if used.UsedAt != nil {
+		a.revokeReused(w, used)
+		return
+	}
+	if !used.Session.Active(now) || !now.Before(used.ExpiresAt) {
+		handleErr(w, http.StatusUnauthorized, "Refresh token expired or revoked", nil)
+		return
+	}
+	u, err := a.userRepository.Read(used.Session.UserID) // Picks up role changes
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			a.revokeSession(w, used, "User no longer exists, session revoked")
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to read user", err.Error())
+		}
+		return
+	}
+
+	raw, hash, err := token.NewRefreshToken()
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to generate refresh token", err.Error())
+		return
+	}
+	next := &RefreshToken{
+		ID:        uuid.New(),
+		SessionID: used.SessionID,
+		TokenHash: hash,
+		ExpiresAt: now.Add(a.tokens.RefreshTTL()),
+	}
+	rotated, err := a.repository.Rotate(used, next, now)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to rotate refresh token", err.Error())
+		return
+	}
+	if !rotated {
+		a.revokeReused(w, used) // Lost a race against another use of the same token
+		return
+	}
+
+	tokenDTO, err := a.tokenPair(u, used.SessionID, raw, next.ExpiresAt, now)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to issue access token", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(tokenDTO); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode response", err.Error())
+		return
+	}
+}
+
+// revokeReused ends the session of a refresh token presented a second time
+func (a *API) revokeReused(w http.ResponseWriter, used *RefreshToken) {
+	a.revokeSession(w, used, "Refresh token reuse detected, session revoked")
+}
+
+// revokeSession ends the session of the refresh token and rejects the request with 401
+func (a *API) revokeSession(w http.ResponseWriter, used *RefreshToken, message string) {
+	if _, err := a.repository.Revoke(used.Session.UserID, used.SessionID, time.Now()); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to revoke session", err.Error())
+		return
+	}
+	handleErr(w, http.StatusUnauthorized, message, used.SessionID.String())
+}
+
+// ListSessions godoc
+//
+//	@summary		List sessions
+//	@description	List the caller's active sessions, the devices that can refresh their tokens
+//	@tags			auth
+//	@accept			json
+//	@produce		json
+//	@security		BearerAuth
+//	@success		200	{array}		SessionDTO
+//	@failure		401	{object}	map[string]string "Unauthorized"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/me/sessions [get]
+func (a *API) ListSessions(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	c, ok := caller.FromRequest(r)
+	if !ok {
+		handleErr(w, http.StatusUnauthorized, "Caller not identified", nil)
+		return
+	}
+
+	sessions, err := a.repository.ListActive(c.UserID, time.Now())
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to retrieve sessions", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(sessions.ToDto(c.SessionID)); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode response", err.Error())
+		return
+	}
+}
+
+// RevokeSession godoc
+//
+//	@summary		Revoke session
+//	@description	Log out one of the caller's sessions; its refresh token stops working and
+//	@description	issued access tokens are rejected within 30 seconds (caller.SessionCacheTTL)
+//	@tags			auth
+//	@accept			json
+//	@produce		json
+//	@security		BearerAuth
+//	@param			sessionId	path	string	true	"Session ID (UUID)"
+//	@success		200	{object}	map[string]string "Successfully revoked"
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		401	{object}	map[string]string "Unauthorized"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/me/sessions/{sessionId} [delete]
+func (a *API) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	c, ok := caller.FromRequest(r)
+	if !ok {
+		handleErr(w, http.StatusUnauthorized, "Caller not identified", nil)
+		return
+	}
+	sessionID, err := uuid.Parse(chi.URLParam(r, "sessionId"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid session ID format (must be UUID)", err.Error())
+		return
+	}
+
+	rowsAffected, err := a.repository.Revoke(c.UserID, sessionID, time.Now())
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to revoke session", err.Error())
+		return
+	}
+	if rowsAffected == 0 {
+		handleErr(w, http.StatusNotFound, "Session not found or already revoked", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Session revoked successfully"})
+}
+
+// RevokeAllSessions godoc
+//
+//	@summary		Revoke all sessions
+//	@description	Log out the caller on every device, including the current one
+//	@tags			auth
+//	@accept			json
+//	@produce		json
+//	@security		BearerAuth
+//	@success		200	{object}	map[string]interface{} "Number of revoked sessions"
+//	@failure		401	{object}	map[string]string "Unauthorized"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/me/sessions [delete]
+func (a *API) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	c, ok := caller.FromRequest(r)
+	if !ok {
+		handleErr(w, http.StatusUnauthorized, "Caller not identified", nil)
+		return
+	}
+
+	rowsAffected, err := a.repository.RevokeAll(c.UserID, time.Now())
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to revoke sessions", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{"message": "Sessions revoked successfully", "revoked": rowsAffected})
+}
+
+// checkSecondFactor requires a valid TOTP or recovery code from users with
+// two-factor authentication. Returns false if an error response has been written.
+func (a *API) checkSecondFactor(w http.ResponseWriter, userID uuid.UUID, code string) bool {
+	twoFactor, err := a.enabledTwoFactor(userID)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to read two-factor settings", err.Error())
+		return false
+	}
+	if twoFactor == nil {
+		return true
+	}
+	if code == "" {
+		handleErr(w, http.StatusUnauthorized, "Two-factor code required", "two_factor_required")
+		return false
+	}
+	return a.verifyCode(w, twoFactor, code)
+}
+
+// verifyCode checks a TOTP or recovery code of the enrolment, answering 429
+// while codes are refused after too many invalid ones. Returns false if an
+// error response has been written.
+func (a *API) verifyCode(w http.ResponseWriter, twoFactor *TwoFactor, code string) bool {
+	now := time.Now()
+	ok, err := a.verifySecondFactor(twoFactor, code, now)
+	if errors.Is(err, ErrTwoFactorLocked) {
+		retryAfter := int(math.Ceil(TwoFactorLockout.Seconds()))
+		if twoFactor.Locked(now) { // Not if a valid code has just lifted the lock
+			retryAfter = int(math.Ceil(twoFactor.LockedUntil.Sub(now).Seconds()))
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		handleErr(w, http.StatusTooManyRequests, "Too many invalid two-factor codes, try again later", "two_factor_locked")
+		return false
+	}
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to verify two-factor code", err.Error())
+		return false
+	}
+	if !ok {
+		handleErr(w, http.StatusUnauthorized, "Invalid two-factor code", nil)
+		return false
+	}
+	return true
+}
+
+// EnrollTwoFactor godoc
+//
+//	@summary		Enroll in two-factor authentication
+//	@description	Generate a TOTP key for an authenticator app. Two-factor authentication is only
+//	@description	enabled once a code from the app is confirmed; enrolling again replaces a pending key.
+//	@tags			auth
+//	@accept			json
+//	@produce		json
+//	@security		BearerAuth
+//	@success		201	{object}	EnrollmentDTO "Returns the key and its otpauth URI"
+//	@failure		401	{object}	map[string]string "Unauthorized"
+//	@failure		404	{object}	map[string]string "User not found"
+//	@failure		409	{object}	map[string]string "Two-factor authentication already enabled"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/me/2fa [post]
+func (a *API) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	c, ok := caller.FromRequest(r)
+	if !ok {
+		handleErr(w, http.StatusUnauthorized, "Caller not identified", nil)
+		return
+	}
+
+	u, err := a.userRepository.Read(c.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			handleErr(w, http.StatusNotFound, "User not found", nil)
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to read user", err.Error())
+		}
+		return
+	}
+	if twoFactor, err := a.enabledTwoFactor(u.ID); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to read two-factor settings", err.Error())
+		return
+	} else if twoFactor != nil {
+		handleErr(w, http.StatusConflict, "Two-factor authentication already enabled", nil)
+		return
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to generate key", err.Error())
+		return
+	}
+	twoFactor := &TwoFactor{
+		ID:     uuid.New(),
+		UserID: u.ID,
+		Secret: secret,
+	}
+	if err := a.repository.Enroll(twoFactor); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to store enrolment", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&EnrollmentDTO{
+		Secret:     secret,
+		OtpauthURI: totp.URI(token.Issuer, u.Username, secret),
+	})
+}
+
+// ConfirmTwoFactor godoc
+//
+//	@summary		Confirm two-factor authentication
+//	@description	Enable two-factor authentication with a code from the enrolled authenticator app.
+//	@description	Returns single use recovery codes; they are stored hashed and cannot be shown again.
+//	@tags			auth
+//	@accept			json
+//	@produce		json
+//	@security		BearerAuth
+//	@param			body	body		TwoFactorCodeForm	true	"TOTP code"
+//	@success		200	{object}	RecoveryCodesDTO
+//	@failure		400	{object}	map[string]interface{} "Bad Request (Invalid JSON or Validation Errors)"
+//	@failure		401	{object}	map[string]string "Unauthorized"
+//	@failure		404	{object}	map[string]string "No pending enrolment"
+//	@failure		409	{object}	map[string]string "Two-factor authentication already enabled"
+//	@failure		422	{object}	map[string]string "Invalid code"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/me/2fa/confirm [post]
+func (a *API) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	c, ok := caller.FromRequest(r)
+	if !ok {
+		handleErr(w, http.StatusUnauthorized, "Caller not identified", nil)
+		return
+	}
+	form := &TwoFactorCodeForm{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid request body JSON", err.Error())
+		return
+	}
+	if err := a.validate.Struct(form); err != nil {
+		handleErr(w, http.StatusBadRequest, "Validation failed", formatValidationErrors(err))
+		return
+	}
+
+	twoFactor, err := a.repository.FindTwoFactor(c.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			handleErr(w, http.StatusNotFound, "No pending two-factor enrolment", nil)
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to read two-factor settings", err.Error())
+		}
+		return
+	}
+	if twoFactor.EnabledAt != nil {
+		handleErr(w, http.StatusConflict, "Two-factor authentication already enabled", nil)
+		return
+	}
+
+	now := time.Now()
+	step, ok := totp.Verify(twoFactor.Secret, form.Code, now, twoFactor.LastStep)
+	if !ok {
+		handleErr(w, http.StatusUnprocessableEntity, "Invalid code", nil)
+		return
+	}
+	raw, codes, err := newRecoveryCodes(c.UserID)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to generate recovery codes", err.Error())
+		return
+	}
+	enabled, err := a.repository.EnableTwoFactor(twoFactor, codes, step, now)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to enable two-factor authentication", err.Error())
+		return
+	}
+	if !enabled {
+		handleErr(w, http.StatusConflict, "Two-factor authentication already enabled", nil) // Confirmed concurrently
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&RecoveryCodesDTO{RecoveryCodes: raw}); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode response", err.Error())
+		return
+	}
+}
+
+// DisableTwoFactor godoc
+//
+//	@summary		Disable two-factor authentication
+//	@description	Turn two-factor authentication off and discard the recovery codes. Requires the
+//	@description	password and a TOTP or recovery code, so a stolen access token is not enough.
+//	@tags			auth
+//	@accept			json
+//	@produce		json
+//	@security		BearerAuth
+//	@param			body	body		DisableTwoFactorForm	true	"Re-authentication"
+//	@success		200	{object}	map[string]string "Successfully disabled"
+//	@failure		400	{object}	map[string]interface{} "Bad Request (Invalid JSON or Validation Errors)"
+//	@failure		401	{object}	map[string]string "Invalid password or two-factor code"
+//	@failure		429	{object}	map[string]string "Too many invalid two-factor codes, see Retry-After"
+//	@failure		404	{object}	map[string]string "Two-factor authentication not enabled"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/me/2fa [delete]
+func (a *API) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	c, ok := caller.FromRequest(r)
+	if !ok {
+		handleErr(w, http.StatusUnauthorized, "Caller not identified", nil)
+		return
+	}
+	form := &DisableTwoFactorForm{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid request body JSON", err.Error())
+		return
+	}
+	if err := a.validate.Struct(form); err != nil {
+		handleErr(w, http.StatusBadRequest, "Validation failed", formatValidationErrors(err))
+		return
+	}
+
+	u, err := a.userRepository.Read(c.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			handleErr(w, http.StatusNotFound, "User not found", nil)
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to read user", err.Error())
+		}
+		return
+	}
+	if !u.CheckPassword(form.Password) {
+		handleErr(w, http.StatusUnauthorized, "Invalid password", nil)
+		return
+	}
+	twoFactor, err := a.enabledTwoFactor(u.ID)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to read two-factor settings", err.Error())
+		return
+	}
+	if twoFactor == nil {
+		handleErr(w, http.StatusNotFound, "Two-factor authentication not enabled", nil)
+		return
+	}
+	if !a.verifyCode(w, twoFactor, form.Code) {
+		return
+	}
+
+	if err := a.repository.DisableTwoFactor(u.ID); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to disable two-factor authentication", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Two-factor authentication disabled"})
+}
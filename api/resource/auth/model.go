@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"fitapp-backend/api/resource/user"
+)
+
+// MaxDeviceLength caps the stored device name of a session
+const MaxDeviceLength = 255
+
+// Session represents a login on one device, the structure of the 'sessions'
+// table. Its refresh tokens form one rotating family.
+type Session struct {
+	ID        uuid.UUID `gorm:"type:uuid;primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	UserID     uuid.UUID  `gorm:"type:uuid;not null"`
+	Device     string     `gorm:"not null"` // Name sent by the client, or its User-Agent
+	IPAddress  string     `gorm:"not null"`
+	LastUsedAt time.Time  `gorm:"not null"`
+	ExpiresAt  time.Time  `gorm:"not null"` // Expiry of the latest refresh token
+	RevokedAt  *time.Time // Set on logout, revocation or detected token reuse
+}
+
+// Sessions is a slice of Session pointers
+type Sessions []*Session
+
+// RefreshToken represents an issued refresh token, the structure of the
+// 'refresh_tokens' table. Tokens are single use; using one marks it used and
+// issues its successor in the same session.
+type RefreshToken struct {
+	ID        uuid.UUID `gorm:"type:uuid;primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	SessionID uuid.UUID  `gorm:"type:uuid;not null"`
+	TokenHash string     `gorm:"not null"` // Hex SHA-256 of the token
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time // Set when rotated; presenting it again is reuse
+
+	Session Session `gorm:"foreignKey:SessionID"`
+}
+
+// TwoFactor represents a user's TOTP enrolment, the structure of the
+// 'two_factors' table. It is pending until a code confirms the authenticator app.
+type TwoFactor struct {
+	ID        uuid.UUID `gorm:"type:uuid;primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	UserID    uuid.UUID  `gorm:"type:uuid;not null"`
+	Secret    string     `gorm:"not null"` // Base32 TOTP key, kept readable to verify codes
+	EnabledAt *time.Time // Set once a code confirmed the enrolment
+	LastStep  int64      `gorm:"not null"` // Time step of the last accepted code, earlier codes are rejected
+
+	FailedAttempts int        `gorm:"not null"` // Codes checked since the last valid one, restarts after a lockout
+	LockedUntil    *time.Time // Codes are refused until then after MaxTwoFactorAttempts invalid ones
+}
+
+// RecoveryCode represents a single use code replacing a TOTP code when the
+// authenticator is lost, the structure of the 'recovery_codes' table
+type RecoveryCode struct {
+	ID        uuid.UUID `gorm:"type:uuid;primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	UserID   uuid.UUID  `gorm:"type:uuid;not null"`
+	CodeHash string     `gorm:"not null"` // Hex SHA-256 of the normalized code
+	UsedAt   *time.Time // Set when the code has been used
+}
+
+// RecoveryCodes is a slice of RecoveryCode pointers
+type RecoveryCodes []*RecoveryCode
+
+// RegisterForm represents the data for creating an account: the user profile
+// plus the password to log in with
+type RegisterForm struct {
+	user.Form
+	Password string `json:"password" validate:"required,min=8,max=72"` // bcrypt uses at most 72 bytes
+	Device   string `json:"device"`                                    // Optional session name, defaults to the User-Agent
+}
+
+// LoginForm represents the credentials for logging in
+type LoginForm struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+	Device   string `json:"device"` // Optional session name, defaults to the User-Agent
+	Code     string `json:"code"`   // TOTP or recovery code, required once two-factor authentication is enabled
+}
+
+// RefreshForm represents a refresh token exchanged for a new token pair
+type RefreshForm struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// TwoFactorCodeForm represents a TOTP code confirming an enrolment
+type TwoFactorCodeForm struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// DisableTwoFactorForm represents the re-authentication required to turn
+// two-factor authentication off
+type DisableTwoFactorForm struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"` // TOTP or recovery code
+}
+
+// TokenDTO represents an issued token pair
+type TokenDTO struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"` // Always "Bearer"
+	ExpiresIn        int    `json:"expires_in"` // Seconds until the access token expires
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int    `json:"refresh_expires_in"` // Seconds until the refresh token expires
+}
+
+// RegisterDTO represents a created account together with its first tokens
+type RegisterDTO struct {
+	User *user.DTO `json:"user"`
+	*TokenDTO
+}
+
+// SessionDTO represents an active session
+type SessionDTO struct {
+	ID         string `json:"id"`
+	Device     string `json:"device"`
+	IPAddress  string `json:"ip_address"`
+	CreatedAt  string `json:"created_at"`
+	LastUsedAt string `json:"last_used_at"`
+	ExpiresAt  string `json:"expires_at"`
+	Current    bool   `json:"current"` // The session of the requesting access token
+}
+
+// EnrollmentDTO represents a pending TOTP enrolment to add to an authenticator app
+type EnrollmentDTO struct {
+	Secret     string `json:"secret"`      // Base32 key for manual entry
+	OtpauthURI string `json:"otpauth_uri"` // Key URI, usually shown as a QR code
+}
+
+// RecoveryCodesDTO represents freshly generated recovery codes, shown only once
+type RecoveryCodesDTO struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
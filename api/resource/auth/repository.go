@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository handles database operations for sessions, refresh_tokens and
+// the two-factor tables
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new auth repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// CreateSession inserts a session together with its first refresh token
+func (r *Repository) CreateSession(session *Session, refreshToken *RefreshToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		return tx.Omit("Session").Create(refreshToken).Error
+	})
+}
+
+// FindRefreshToken retrieves a refresh token with its session by the token hash
+func (r *Repository) FindRefreshToken(hash string) (*RefreshToken, error) {
+	refreshToken := &RefreshToken{}
+	if err := r.db.Preload("Session").Where("token_hash = ?", hash).First(refreshToken).Error; err != nil {
+		return nil, err // Can be gorm.ErrRecordNotFound
+	}
+	return refreshToken, nil
+}
+
+// Rotate marks the used refresh token and stores its successor, extending the
+// session. Returns false without changes if the token has been used
+// concurrently, which callers must treat as reuse.
+func (r *Repository) Rotate(used *RefreshToken, next *RefreshToken, now time.Time) (bool, error) {
+	rotated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&RefreshToken{}).
+			Where("id = ? AND used_at IS NULL", used.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		if err := tx.Omit("Session").Create(next).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&Session{}).
+			Where("id = ?", next.SessionID).
+			Updates(map[string]interface{}{"last_used_at": now, "expires_at": next.ExpiresAt}).Error
+		if err != nil {
+			return err
+		}
+		rotated = true
+		return nil
+	})
+	return rotated, err
+}
+
+// ListActive retrieves the user's sessions which are neither revoked nor
+// expired, most recently used first
+func (r *Repository) ListActive(userID uuid.UUID, now time.Time) (Sessions, error) {
+	sessions := make([]*Session, 0)
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// SessionActive reports whether the session is neither revoked nor expired
+func (r *Repository) SessionActive(sessionID uuid.UUID, now time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&Session{}).
+		Where("id = ? AND revoked_at IS NULL AND expires_at > ?", sessionID, now).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Revoke ends a session of the user; its refresh tokens stop working
+func (r *Repository) Revoke(userID uuid.UUID, sessionID uuid.UUID, now time.Time) (int64, error) {
+	result := r.db.Model(&Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Update("revoked_at", now)
+	return result.RowsAffected, result.Error
+}
+
+// RevokeAll ends all sessions of the user
+func (r *Repository) RevokeAll(userID uuid.UUID, now time.Time) (int64, error) {
+	result := r.db.Model(&Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now)
+	return result.RowsAffected, result.Error
+}
+
+// FindTwoFactor retrieves the user's TOTP enrolment, pending or enabled
+func (r *Repository) FindTwoFactor(userID uuid.UUID) (*TwoFactor, error) {
+	twoFactor := &TwoFactor{}
+	if err := r.db.Where("user_id = ?", userID).First(twoFactor).Error; err != nil {
+		return nil, err // Can be gorm.ErrRecordNotFound
+	}
+	return twoFactor, nil
+}
+
+// Enroll stores a pending enrolment, replacing an earlier pending one
+func (r *Repository) Enroll(twoFactor *TwoFactor) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ? AND enabled_at IS NULL", twoFactor.UserID).Delete(&TwoFactor{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(twoFactor).Error
+	})
+}
+
+// EnableTwoFactor confirms a pending enrolment at the accepted time step and
+// replaces the user's recovery codes. Returns false without changes if the
+// enrolment is no longer pending.
+func (r *Repository) EnableTwoFactor(twoFactor *TwoFactor, codes RecoveryCodes, step int64, now time.Time) (bool, error) {
+	enabled := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&TwoFactor{}).
+			Where("id = ? AND enabled_at IS NULL", twoFactor.ID).
+			Updates(map[string]interface{}{"enabled_at": now, "last_step": step})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		if err := tx.Where("user_id = ?", twoFactor.UserID).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&codes).Error; err != nil {
+			return err
+		}
+		enabled = true
+		return nil
+	})
+	return enabled, err
+}
+
+// UseStep records the time step of an accepted TOTP code. Returns false if a
+// code of the same or a later step has been accepted concurrently.
+func (r *Repository) UseStep(id uuid.UUID, step int64) (bool, error) {
+	result := r.db.Model(&TwoFactor{}).
+		Where("id = ? AND last_step < ?", id, step).
+		Update("last_step", step)
+	return result.RowsAffected == 1, result.Error
+}
+
+// attemptsSoFar is the stored count of invalid codes, which restarts once a
+// lockout has expired
+const attemptsSoFar = "CASE WHEN locked_until IS NULL THEN failed_attempts ELSE 0 END"
+
+// CountAttempt counts a code attempt before it is checked, unless the
+// enrolment is locked at now. The MaxTwoFactorAttempts-th consecutive one
+// locks it until lockedUntil. Checking the lock and counting is one statement,
+// so concurrent attempts cannot check more codes than allowed. Returns false
+// while locked, with twoFactor.LockedUntil reloaded.
+func (r *Repository) CountAttempt(twoFactor *TwoFactor, now time.Time, lockedUntil time.Time) (bool, error) {
+	result := r.db.Model(twoFactor).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "failed_attempts"}, {Name: "locked_until"}}}).
+		Where("locked_until IS NULL OR locked_until <= ?", now).
+		Updates(map[string]interface{}{
+			"failed_attempts": gorm.Expr(attemptsSoFar + " + 1"),
+			"locked_until":    gorm.Expr("CASE WHEN "+attemptsSoFar+" + 1 >= ? THEN ? ELSE NULL END", MaxTwoFactorAttempts, lockedUntil),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return true, nil
+	}
+	return false, r.db.Select("locked_until").First(twoFactor).Error
+}
+
+// ResetFailedAttempts clears the count of invalid codes, and the lock it may
+// have reached, after a valid code
+func (r *Repository) ResetFailedAttempts(id uuid.UUID) error {
+	return r.db.Model(&TwoFactor{}).
+		Where("id = ? AND (failed_attempts > 0 OR locked_until IS NOT NULL)", id).
+		Updates(map[string]interface{}{"failed_attempts": 0, "locked_until": nil}).Error
+}
+
+// UseRecoveryCode marks an unused recovery code of the user as used. Returns
+// false if no such code exists.
+func (r *Repository) UseRecoveryCode(userID uuid.UUID, hash string, now time.Time) (bool, error) {
+	result := r.db.Model(&RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", now)
+	return result.RowsAffected == 1, result.Error
+}
+
+// DisableTwoFactor removes the user's enrolment and recovery codes
+func (r *Repository) DisableTwoFactor(userID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&TwoFactor{}).Error
+	})
+}
@@ -0,0 +1,303 @@
+package auth_test
+
+import (
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+
+	"fitapp-backend/api/resource/auth"
+	"fitapp-backend/api/resource/common/token"
+	mockDB "fitapp-backend/mock/db"
+	testUtil "fitapp-backend/util/test"
+)
+
+var sessionColumns = []string{"id", "created_at", "updated_at", "deleted_at", "user_id", "device", "ip_address", "last_used_at", "expires_at", "revoked_at"}
+
+func TestRepository_CreateSession(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := auth.NewRepository(db)
+
+	now := time.Now()
+	_, hash, err := token.NewRefreshToken()
+	testUtil.NoError(t, err)
+	session := &auth.Session{
+		ID:         uuid.New(),
+		UserID:     uuid.New(),
+		Device:     "Pixel 8",
+		IPAddress:  "192.0.2.1",
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(token.DefaultRefreshTTL),
+	}
+	refreshToken := &auth.RefreshToken{ID: uuid.New(), SessionID: session.ID, TokenHash: hash, ExpiresAt: session.ExpiresAt}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "sessions" ("id","created_at","updated_at","deleted_at","user_id","device","ip_address","last_used_at","expires_at","revoked_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)`)).
+		WithArgs(session.ID, mockDB.AnyTime{}, mockDB.AnyTime{}, nil, session.UserID, "Pixel 8", "192.0.2.1", now, session.ExpiresAt, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "refresh_tokens" ("id","created_at","updated_at","deleted_at","session_id","token_hash","expires_at","used_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`)).
+		WithArgs(refreshToken.ID, mockDB.AnyTime{}, mockDB.AnyTime{}, nil, session.ID, hash, session.ExpiresAt, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	testUtil.NoError(t, repo.CreateSession(session, refreshToken))
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Rotate(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := auth.NewRepository(db)
+
+	now := time.Now()
+	used := &auth.RefreshToken{ID: uuid.New(), SessionID: uuid.New()}
+	next := &auth.RefreshToken{ID: uuid.New(), SessionID: used.SessionID, TokenHash: "next", ExpiresAt: now.Add(time.Hour)}
+	markUsedSQL := regexp.QuoteMeta(`UPDATE "refresh_tokens" SET "used_at"=$1,"updated_at"=$2 WHERE (id = $3 AND used_at IS NULL) AND "refresh_tokens"."deleted_at" IS NULL`)
+
+	// The token was used concurrently: nothing else happens
+	mock.ExpectBegin()
+	mock.ExpectExec(markUsedSQL).WithArgs(now, mockDB.AnyTime{}, used.ID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	rotated, err := repo.Rotate(used, next, now)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, false, rotated)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(markUsedSQL).WithArgs(now, mockDB.AnyTime{}, used.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "refresh_tokens"`)).
+		WithArgs(next.ID, mockDB.AnyTime{}, mockDB.AnyTime{}, nil, used.SessionID, "next", next.ExpiresAt, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "sessions" SET "expires_at"=$1,"last_used_at"=$2,"updated_at"=$3 WHERE id = $4 AND "sessions"."deleted_at" IS NULL`)).
+		WithArgs(next.ExpiresAt, now, mockDB.AnyTime{}, used.SessionID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	rotated, err = repo.Rotate(used, next, now)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, true, rotated)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_ListActive(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := auth.NewRepository(db)
+
+	now := time.Now()
+	userID, current := uuid.New(), uuid.New()
+	mockRows := sqlmock.NewRows(sessionColumns).
+		AddRow(current, now, now, nil, userID, "Pixel 8", "192.0.2.1", now, now.Add(time.Hour), nil).
+		AddRow(uuid.New(), now, now, nil, userID, "curl/8.5.0", "192.0.2.7", now.Add(-time.Hour), now.Add(time.Hour), nil)
+
+	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "sessions" WHERE (user_id = $1 AND revoked_at IS NULL AND expires_at > $2) AND "sessions"."deleted_at" IS NULL ORDER BY last_used_at DESC`)
+	mock.ExpectQuery(expectedSQL).WithArgs(userID, now).WillReturnRows(mockRows)
+
+	sessions, err := repo.ListActive(userID, now)
+	testUtil.NoError(t, err)
+	dtos := sessions.ToDto(current)
+	testUtil.Equal(t, 2, len(dtos))
+	testUtil.Equal(t, true, dtos[0].Current)
+	testUtil.Equal(t, false, dtos[1].Current)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_SessionActive(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := auth.NewRepository(db)
+
+	now := time.Now()
+	sessionID := uuid.New()
+	expectedSQL := regexp.QuoteMeta(`SELECT count(*) FROM "sessions" WHERE (id = $1 AND revoked_at IS NULL AND expires_at > $2) AND "sessions"."deleted_at" IS NULL`)
+	mock.ExpectQuery(expectedSQL).WithArgs(sessionID, now).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(expectedSQL).WithArgs(sessionID, now).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0)) // Revoked in the meantime
+
+	active, err := repo.SessionActive(sessionID, now)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, true, active)
+	active, err = repo.SessionActive(sessionID, now)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, false, active)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_RevokeAll(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := auth.NewRepository(db)
+
+	now := time.Now()
+	userID := uuid.New()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "sessions" SET "revoked_at"=$1,"updated_at"=$2 WHERE (user_id = $3 AND revoked_at IS NULL) AND "sessions"."deleted_at" IS NULL`)).
+		WithArgs(now, mockDB.AnyTime{}, userID).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	rowsAffected, err := repo.RevokeAll(userID, now)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, int64(3), rowsAffected)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_UseStep(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := auth.NewRepository(db)
+
+	id := uuid.New()
+	useStepSQL := regexp.QuoteMeta(`UPDATE "two_factors" SET "last_step"=$1,"updated_at"=$2 WHERE (id = $3 AND last_step < $4) AND "two_factors"."deleted_at" IS NULL`)
+	mock.ExpectBegin()
+	mock.ExpectExec(useStepSQL).WithArgs(int64(100), mockDB.AnyTime{}, id, int64(100)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	// The same code again: the step is no longer newer than the stored one
+	mock.ExpectBegin()
+	mock.ExpectExec(useStepSQL).WithArgs(int64(100), mockDB.AnyTime{}, id, int64(100)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	used, err := repo.UseStep(id, 100)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, true, used)
+	used, err = repo.UseStep(id, 100)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, false, used)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_UseRecoveryCode(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := auth.NewRepository(db)
+
+	userID := uuid.New()
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "recovery_codes" SET "used_at"=$1,"updated_at"=$2 WHERE (user_id = $3 AND code_hash = $4 AND used_at IS NULL) AND "recovery_codes"."deleted_at" IS NULL`)).
+		WithArgs(now, mockDB.AnyTime{}, userID, "hash").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	used, err := repo.UseRecoveryCode(userID, "hash", now)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, false, used)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+// countAttemptSQL checks the lock and counts the attempt in one statement
+var countAttemptSQL = regexp.QuoteMeta(`UPDATE "two_factors" SET "failed_attempts"=CASE WHEN locked_until IS NULL THEN failed_attempts ELSE 0 END + 1,"locked_until"=CASE WHEN CASE WHEN locked_until IS NULL THEN failed_attempts ELSE 0 END + 1 >= $1 THEN $2 ELSE NULL END,"updated_at"=$3 WHERE (locked_until IS NULL OR locked_until <= $4) AND "two_factors"."deleted_at" IS NULL AND "id" = $5 RETURNING "failed_attempts","locked_until"`) + "$"
+
+func TestRepository_CountAttempt(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := auth.NewRepository(db)
+
+	id := uuid.New()
+	now := time.Now()
+	lockedUntil := now.Add(auth.TwoFactorLockout)
+	mock.ExpectBegin()
+	mock.ExpectQuery(countAttemptSQL).
+		WithArgs(auth.MaxTwoFactorAttempts, lockedUntil, mockDB.AnyTime{}, now, id).
+		WillReturnRows(sqlmock.NewRows([]string{"failed_attempts", "locked_until"}).AddRow(auth.MaxTwoFactorAttempts, lockedUntil))
+	mock.ExpectCommit()
+	// Once locked, the attempt is refused and the lock is reloaded
+	mock.ExpectBegin()
+	mock.ExpectQuery(countAttemptSQL).
+		WithArgs(auth.MaxTwoFactorAttempts, lockedUntil, mockDB.AnyTime{}, now, id).
+		WillReturnRows(sqlmock.NewRows([]string{"failed_attempts", "locked_until"}))
+	mock.ExpectCommit()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "locked_until" FROM "two_factors" WHERE "two_factors"."deleted_at" IS NULL AND "two_factors"."id" = $1 ORDER BY "two_factors"."id" LIMIT $2`)).
+		WithArgs(id, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"locked_until"}).AddRow(lockedUntil))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "two_factors" SET "failed_attempts"=$1,"locked_until"=$2,"updated_at"=$3 WHERE (id = $4 AND (failed_attempts > 0 OR locked_until IS NOT NULL)) AND "two_factors"."deleted_at" IS NULL`)).
+		WithArgs(0, nil, mockDB.AnyTime{}, id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	twoFactor := &auth.TwoFactor{ID: id}
+	counted, err := repo.CountAttempt(twoFactor, now, lockedUntil)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, true, counted)
+	testUtil.Equal(t, auth.MaxTwoFactorAttempts, twoFactor.FailedAttempts)
+
+	twoFactor = &auth.TwoFactor{ID: id}
+	counted, err = repo.CountAttempt(twoFactor, now, lockedUntil)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, false, counted)
+	testUtil.Equal(t, true, twoFactor.Locked(now))
+
+	testUtil.NoError(t, repo.ResetFailedAttempts(id))
+
+	testUtil.Equal(t, true, (&auth.TwoFactor{LockedUntil: &lockedUntil}).Locked(now))
+	testUtil.Equal(t, false, (&auth.TwoFactor{LockedUntil: &now}).Locked(now))
+	testUtil.Equal(t, false, (&auth.TwoFactor{}).Locked(now))
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_CountAttempt_Concurrent(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := auth.NewRepository(db)
+
+	id := uuid.New()
+	now := time.Now()
+	lockedUntil := now.Add(auth.TwoFactorLockout)
+	attempts := auth.MaxTwoFactorAttempts + 3
+	// Guesses race each other, so the database applies the conditional updates
+	// in whatever order they arrive: only the first MaxTwoFactorAttempts match
+	mock.MatchExpectationsInOrder(false)
+	for i := 1; i <= attempts; i++ {
+		rows := sqlmock.NewRows([]string{"failed_attempts", "locked_until"})
+		if i <= auth.MaxTwoFactorAttempts {
+			rows.AddRow(i, nil)
+		}
+		mock.ExpectBegin()
+		mock.ExpectQuery(countAttemptSQL).
+			WithArgs(auth.MaxTwoFactorAttempts, lockedUntil, mockDB.AnyTime{}, now, id).
+			WillReturnRows(rows)
+		mock.ExpectCommit()
+	}
+	for i := auth.MaxTwoFactorAttempts; i < attempts; i++ {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "locked_until" FROM "two_factors"`)).
+			WithArgs(id, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"locked_until"}).AddRow(lockedUntil))
+	}
+
+	var wg sync.WaitGroup
+	counted := make([]bool, attempts)
+	errs := make([]error, attempts)
+	for i := range counted {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			counted[i], errs[i] = repo.CountAttempt(&auth.TwoFactor{ID: id}, now, lockedUntil)
+		}(i)
+	}
+	wg.Wait()
+
+	checked := 0
+	for i := range counted {
+		testUtil.NoError(t, errs[i])
+		if counted[i] {
+			checked++
+		}
+	}
+	testUtil.Equal(t, auth.MaxTwoFactorAttempts, checked)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"fitapp-backend/api/resource/common/token"
+	"fitapp-backend/api/resource/common/totp"
+	"fitapp-backend/api/resource/user"
+)
+
+// TokenType is the scheme clients send access tokens with
+const TokenType = "Bearer"
+
+// Recovery codes issued when two-factor authentication is enabled
+const (
+	RecoveryCodeCount  = 10
+	recoveryCodeLength = 10 // Base32 characters, 50 random bits
+)
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Throttling of two-factor codes: after MaxTwoFactorAttempts consecutive invalid
+// codes, no code is accepted for TwoFactorLockout
+const (
+	MaxTwoFactorAttempts = 5
+	TwoFactorLockout     = 15 * time.Minute
+)
+
+// ErrTwoFactorLocked is returned while codes are refused after too many invalid ones
+var ErrTwoFactorLocked = errors.New("too many invalid two-factor codes")
+
+// dummyPasswordHash is compared against when the username is unknown, so that
+// failed logins take the same time whether or not the user exists
+const dummyPasswordHash = "$2a$10$gMoJR7iFINPQGkO8iloXEeXbG4ntWUPeuoNVlZVr4KucpzAYxVSb2"
+
+// startSession opens a session for the user on the requesting device and
+// issues its first token pair
+func (a *API) startSession(r *http.Request, u *user.User, device string) (*TokenDTO, error) {
+	now := time.Now()
+	raw, hash, err := token.NewRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	session := &Session{
+		ID:         uuid.New(),
+		UserID:     u.ID,
+		Device:     deviceName(r, device),
+		IPAddress:  clientIP(r),
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(a.tokens.RefreshTTL()),
+	}
+	refreshToken := &RefreshToken{
+		ID:        uuid.New(),
+		SessionID: session.ID,
+		TokenHash: hash,
+		ExpiresAt: session.ExpiresAt,
+	}
+	if err := a.repository.CreateSession(session, refreshToken); err != nil {
+		return nil, err
+	}
+	return a.tokenPair(u, session.ID, raw, refreshToken.ExpiresAt, now)
+}
+
+// tokenPair signs an access token carrying the user's current role for the
+// session and combines it with the refresh token
+func (a *API) tokenPair(u *user.User, sessionID uuid.UUID, refreshToken string, refreshExpiresAt, now time.Time) (*TokenDTO, error) {
+	accessToken, expiresAt, err := a.tokens.Issue(u.ID, sessionID, u.Role, now)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenDTO{
+		AccessToken:      accessToken,
+		TokenType:        TokenType,
+		ExpiresIn:        int(expiresAt.Sub(now).Seconds()),
+		RefreshToken:     refreshToken,
+		RefreshExpiresIn: int(refreshExpiresAt.Sub(now).Seconds()),
+	}, nil
+}
+
+// deviceName returns the client-provided session name, falling back to the User-Agent
+func deviceName(r *http.Request, device string) string {
+	name := strings.TrimSpace(device)
+	if name == "" {
+		name = strings.TrimSpace(r.UserAgent())
+	}
+	if name == "" {
+		return "Unknown device"
+	}
+	if runes := []rune(name); len(runes) > MaxDeviceLength {
+		name = string(runes[:MaxDeviceLength])
+	}
+	return name
+}
+
+// clientIP returns the address of the direct peer of the request
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Active reports whether the session can still be refreshed at now
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// ToDto converts a Session to its DTO representation; current is the
+// session of the requesting access token
+func (s *Session) ToDto(current uuid.UUID) *SessionDTO {
+	return &SessionDTO{
+		ID:         s.ID.String(),
+		Device:     s.Device,
+		IPAddress:  s.IPAddress,
+		CreatedAt:  s.CreatedAt.UTC().Format(time.RFC3339),
+		LastUsedAt: s.LastUsedAt.UTC().Format(time.RFC3339),
+		ExpiresAt:  s.ExpiresAt.UTC().Format(time.RFC3339),
+		Current:    s.ID == current,
+	}
+}
+
+// ToDto converts a slice of Sessions to a slice of DTOs
+func (ss Sessions) ToDto(current uuid.UUID) []*SessionDTO {
+	dtos := make([]*SessionDTO, len(ss))
+	for i, s := range ss {
+		dtos[i] = s.ToDto(current)
+	}
+	return dtos
+}
+
+// enabledTwoFactor returns the user's confirmed TOTP enrolment, or nil if the
+// user has not enabled two-factor authentication
+func (a *API) enabledTwoFactor(userID uuid.UUID) (*TwoFactor, error) {
+	twoFactor, err := a.repository.FindTwoFactor(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.EnabledAt == nil {
+		return nil, nil // Pending enrolments do not protect the account yet
+	}
+	return twoFactor, nil
+}
+
+// Locked reports whether codes are refused at now after too many invalid ones
+func (tf *TwoFactor) Locked(now time.Time) bool {
+	return tf.LockedUntil != nil && now.Before(*tf.LockedUntil)
+}
+
+// verifySecondFactor accepts a TOTP code not used before or an unused recovery
+// code, consuming it. Every code is counted before it is checked and the count
+// is reset by a valid one; while the enrolment is locked after too many invalid
+// codes, every code is refused with ErrTwoFactorLocked.
+func (a *API) verifySecondFactor(twoFactor *TwoFactor, code string, now time.Time) (bool, error) {
+	if twoFactor.Locked(now) {
+		return false, ErrTwoFactorLocked
+	}
+	counted, err := a.repository.CountAttempt(twoFactor, now, now.Add(TwoFactorLockout))
+	if err != nil {
+		return false, err
+	}
+	if !counted {
+		return false, ErrTwoFactorLocked // Locked by concurrent attempts
+	}
+
+	var ok bool
+	if step, valid := totp.Verify(twoFactor.Secret, code, now, twoFactor.LastStep); valid {
+		ok, err = a.repository.UseStep(twoFactor.ID, step)
+	} else {
+		ok, err = a.repository.UseRecoveryCode(twoFactor.UserID, hashRecoveryCode(code), now)
+	}
+	if err != nil || !ok {
+		return false, err
+	}
+	return true, a.repository.ResetFailedAttempts(twoFactor.ID)
+}
+
+// newRecoveryCodes generates recovery codes for the user, returning them
+// formatted for display alongside the models storing their hashes
+func newRecoveryCodes(userID uuid.UUID) ([]string, RecoveryCodes, error) {
+	raw := make([]string, RecoveryCodeCount)
+	codes := make(RecoveryCodes, RecoveryCodeCount)
+	b := make([]byte, recoveryCodeLength*5/8)
+	for i := range raw {
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(recoveryEncoding.EncodeToString(b))
+		raw[i] = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+		codes[i] = &RecoveryCode{
+			ID:       uuid.New(),
+			UserID:   userID,
+			CodeHash: hashRecoveryCode(raw[i]),
+		}
+	}
+	return raw, codes, nil
+}
+
+// hashRecoveryCode returns the hex SHA-256 of a recovery code, ignoring case,
+// spaces and dashes as users may type it differently than displayed
+func hashRecoveryCode(code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
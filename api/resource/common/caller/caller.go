@@ -0,0 +1,151 @@
+package caller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"fitapp-backend/api/resource/common/token"
+)
+
+// Roles a user can have
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin" // Moderates the global catalog, sees and restores all users' data
+)
+
+// Roles lists the valid roles
+var Roles = []string{RoleUser, RoleAdmin}
+
+// Caller identifies the user making a request
+type Caller struct {
+	UserID    uuid.UUID
+	SessionID uuid.UUID // Login session the access token was issued for
+	Role      string
+	Admin     bool // Role is RoleAdmin: may modify other users' data and the verified global catalog
+}
+
+// HasRole reports whether the caller has one of the roles
+func (c *Caller) HasRole(roles ...string) bool {
+	for _, role := range roles {
+		if c.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccess reports whether the caller may access data owned by the user
+func (c *Caller) CanAccess(userID uuid.UUID) bool {
+	return c.Admin || c.UserID == userID
+}
+
+type contextKey struct{}
+
+// WithCaller returns a copy of ctx carrying c
+func WithCaller(ctx context.Context, c *Caller) context.Context {
+	return context.WithValue(ctx, contextKey{}, c)
+}
+
+// FromContext returns the caller stored in ctx, if any
+func FromContext(ctx context.Context) (*Caller, bool) {
+	c, ok := ctx.Value(contextKey{}).(*Caller)
+	return c, ok && c != nil
+}
+
+// FromRequest returns the caller of r, if any
+func FromRequest(r *http.Request) (*Caller, bool) {
+	return FromContext(r.Context())
+}
+
+// CanAccess reports whether the caller of r may access data owned by the user.
+// Unidentified callers may not access any user's data.
+func CanAccess(r *http.Request, userID uuid.UUID) bool {
+	c, ok := FromRequest(r)
+	return ok && c.CanAccess(userID)
+}
+
+// RequireOwner restricts routes whose param path parameter is a user ID to that
+// user and admins. Other callers get 404, as if the user did not exist, so the
+// IDs of other users cannot be probed. Invalid IDs are left to the handlers.
+func RequireOwner(param string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if userID, err := uuid.Parse(chi.URLParam(r, param)); err == nil && !CanAccess(r, userID) {
+				writeErr(w, http.StatusNotFound, "User not found")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Authenticate identifies the caller from the "Authorization: Bearer" access
+// token. Requests without a valid token, or whose token was issued for a
+// session that sessions reports as no longer active, are rejected with 401.
+func Authenticate(tokens *token.Manager, sessions SessionChecker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scheme, raw, found := strings.Cut(r.Header.Get("Authorization"), " ")
+			if !found || !strings.EqualFold(scheme, "Bearer") {
+				unauthorized(w, "Missing bearer token")
+				return
+			}
+			identity, err := tokens.Verify(strings.TrimSpace(raw))
+			if err != nil {
+				unauthorized(w, "Invalid or expired token")
+				return
+			}
+			active, err := sessions(identity.SessionID)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "Failed to check session")
+				return
+			}
+			if !active {
+				unauthorized(w, "Session revoked or expired")
+				return
+			}
+			r = r.WithContext(WithCaller(r.Context(), &Caller{
+				UserID:    identity.UserID,
+				SessionID: identity.SessionID,
+				Role:      identity.Role,
+				Admin:     identity.Role == RoleAdmin,
+			}))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireRole restricts routes to callers with one of the roles. It must run
+// after Authenticate; other callers get 403.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c, ok := FromRequest(r)
+			if !ok {
+				unauthorized(w, "Caller not identified")
+				return
+			}
+			if !c.HasRole(roles...) {
+				writeErr(w, http.StatusForbidden, "Insufficient role")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="fitapp"`)
+	writeErr(w, http.StatusUnauthorized, message)
+}
+
+func writeErr(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
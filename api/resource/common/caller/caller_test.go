@@ -0,0 +1,200 @@
+package caller_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"fitapp-backend/api/resource/common/caller"
+	"fitapp-backend/api/resource/common/token"
+	testUtil "fitapp-backend/util/test"
+)
+
+const secret = "test-secret-of-at-least-32-bytes!"
+
+// activeSessions treats every session as active
+func activeSessions(uuid.UUID) (bool, error) {
+	return true, nil
+}
+
+// newRouter mounts the middlewares the way the API router does, in front of a
+// handler which answers 204
+func newRouter(tokens *token.Manager, sessions caller.SessionChecker) http.Handler {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }
+	r := chi.NewRouter()
+	r.Use(caller.Authenticate(tokens, sessions))
+	r.With(caller.RequireOwner("id")).Get("/users/{id}", ok)
+	r.With(caller.RequireRole(caller.RoleAdmin)).Get("/admin", ok)
+	return r
+}
+
+// bearer issues an access token for a new session of the user
+func bearer(t *testing.T, tokens *token.Manager, userID uuid.UUID, role string) string {
+	t.Helper()
+	signed, _, err := tokens.Issue(userID, uuid.New(), role, time.Now())
+	testUtil.NoError(t, err)
+	return "Bearer " + signed
+}
+
+func TestAuthenticate(t *testing.T) {
+	t.Parallel()
+	tokens := token.NewManager(secret, time.Minute, 0)
+	userID, revokedID := uuid.New(), uuid.New()
+	router := newRouter(tokens, func(sessionID uuid.UUID) (bool, error) {
+		return sessionID != revokedID, nil
+	})
+	valid := bearer(t, tokens, userID, caller.RoleUser)
+	revoked, _, err := tokens.Issue(userID, revokedID, caller.RoleUser, time.Now())
+	testUtil.NoError(t, err)
+	expired, _, err := tokens.Issue(userID, uuid.New(), caller.RoleUser, time.Now().Add(-time.Hour))
+	testUtil.NoError(t, err)
+
+	cases := []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{"valid token", valid, http.StatusNoContent},
+		{"lowercase scheme", "bearer " + strings.TrimPrefix(valid, "Bearer "), http.StatusNoContent},
+		{"missing header", "", http.StatusUnauthorized},
+		{"other scheme", "Basic dXNlcjpwYXNz", http.StatusUnauthorized},
+		{"scheme only", "Bearer", http.StatusUnauthorized},
+		{"malformed token", "Bearer not-a-token", http.StatusUnauthorized},
+		{"expired token", "Bearer " + expired, http.StatusUnauthorized},
+		{"revoked session", "Bearer " + revoked, http.StatusUnauthorized},
+		{"foreign secret", bearer(t, token.NewManager("another-secret-of-at-least-32-bytes", time.Minute, 0), userID, caller.RoleUser), http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users/"+userID.String(), nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			testUtil.Equal(t, tc.want, rec.Code)
+			if tc.want == http.StatusUnauthorized {
+				testUtil.Equal(t, `Bearer realm="fitapp"`, rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestRequireOwner(t *testing.T) {
+	t.Parallel()
+	tokens := token.NewManager(secret, time.Minute, 0)
+	router := newRouter(tokens, activeSessions)
+	userID, otherID := uuid.New(), uuid.New()
+
+	cases := []struct {
+		name string
+		role string
+		path string
+		want int
+	}{
+		{"own user", caller.RoleUser, "/users/" + userID.String(), http.StatusNoContent},
+		{"other user", caller.RoleUser, "/users/" + otherID.String(), http.StatusNotFound}, // Indistinguishable from a missing user
+		{"admin on other user", caller.RoleAdmin, "/users/" + otherID.String(), http.StatusNoContent},
+		{"invalid ID", caller.RoleUser, "/users/not-a-uuid", http.StatusNoContent}, // Left to the handler
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("Authorization", bearer(t, tokens, userID, tc.role))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			testUtil.Equal(t, tc.want, rec.Code)
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	t.Parallel()
+	tokens := token.NewManager(secret, time.Minute, 0)
+	router := newRouter(tokens, activeSessions)
+
+	cases := []struct {
+		name string
+		role string
+		want int
+	}{
+		{"admin", caller.RoleAdmin, http.StatusNoContent},
+		{"user", caller.RoleUser, http.StatusForbidden},
+		{"unknown role", "moderator", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			req.Header.Set("Authorization", bearer(t, tokens, uuid.New(), tc.role))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			testUtil.Equal(t, tc.want, rec.Code)
+		})
+	}
+
+	// Without Authenticate in front there is no caller to check
+	rec := httptest.NewRecorder()
+	handler := caller.RequireRole(caller.RoleAdmin)(http.NotFoundHandler())
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	testUtil.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestAuthenticate_SessionCheckFailed(t *testing.T) {
+	t.Parallel()
+	tokens := token.NewManager(secret, time.Minute, 0)
+	router := newRouter(tokens, func(uuid.UUID) (bool, error) {
+		return false, errors.New("connection refused")
+	})
+
+	userID := uuid.New()
+	req := httptest.NewRequest(http.MethodGet, "/users/"+userID.String(), nil)
+	req.Header.Set("Authorization", bearer(t, tokens, userID, caller.RoleUser))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	testUtil.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestCacheSessions(t *testing.T) {
+	t.Parallel()
+	lookups, active, fail := 0, true, false
+	sessions := caller.CacheSessions(func(uuid.UUID) (bool, error) {
+		lookups++
+		if fail {
+			return false, errors.New("connection refused")
+		}
+		return active, nil
+	}, 20*time.Millisecond)
+	sessionID := uuid.New()
+
+	ok, err := sessions(sessionID)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, true, ok)
+
+	// A revocation is only seen once the cached state expires
+	active = false
+	ok, err = sessions(sessionID)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, true, ok)
+	testUtil.Equal(t, 1, lookups)
+
+	time.Sleep(30 * time.Millisecond)
+	ok, err = sessions(sessionID)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, false, ok)
+	testUtil.Equal(t, 2, lookups)
+
+	// Failed lookups are not cached
+	fail = true
+	_, err = sessions(uuid.New())
+	testUtil.Equal(t, true, err != nil)
+	fail = false
+	_, err = sessions(uuid.New())
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 4, lookups)
+}
@@ -0,0 +1,61 @@
+package caller
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SessionCacheTTL is how long CacheSessions remembers a session's state, and so
+// the longest an access token of a revoked session keeps working
+const SessionCacheTTL = 30 * time.Second
+
+// maxCachedSessions bounds the cache; expired entries are dropped once it is reached
+const maxCachedSessions = 10000
+
+// SessionChecker reports whether the login session an access token was issued
+// for is still active, i.e. neither revoked nor expired
+type SessionChecker func(sessionID uuid.UUID) (bool, error)
+
+type cachedSession struct {
+	active  bool
+	expires time.Time
+}
+
+// CacheSessions wraps check so that each session is looked up at most once per
+// ttl instead of on every request. Errors are not cached.
+func CacheSessions(check SessionChecker, ttl time.Duration) SessionChecker {
+	var mu sync.Mutex
+	cache := make(map[uuid.UUID]cachedSession)
+
+	return func(sessionID uuid.UUID) (bool, error) {
+		now := time.Now()
+		mu.Lock()
+		cached, ok := cache[sessionID]
+		mu.Unlock()
+		if ok && now.Before(cached.expires) {
+			return cached.active, nil
+		}
+
+		active, err := check(sessionID)
+		if err != nil {
+			return false, err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if len(cache) >= maxCachedSessions {
+			for id, c := range cache {
+				if !now.Before(c.expires) {
+					delete(cache, id)
+				}
+			}
+			if len(cache) >= maxCachedSessions {
+				clear(cache)
+			}
+		}
+		cache[sessionID] = cachedSession{active: active, expires: now.Add(ttl)}
+		return active, nil
+	}
+}
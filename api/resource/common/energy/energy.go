@@ -0,0 +1,96 @@
+package energy
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Formulas estimating the basal metabolic rate
+const (
+	MifflinStJeor  = "mifflin_st_jeor" // Default, most accurate for the general population
+	HarrisBenedict = "harris_benedict" // Revised by Roza and Shizgal (1984)
+	KatchMcArdle   = "katch_mcardle"   // Based on lean body mass, requires the body fat percentage
+)
+
+// Activity levels multiplying the BMR into the total daily energy expenditure
+const (
+	Sedentary  = "sedentary"   // Little or no exercise
+	Light      = "light"       // Exercise 1-3 days a week
+	Moderate   = "moderate"    // Exercise 3-5 days a week
+	Active     = "active"      // Exercise 6-7 days a week
+	VeryActive = "very_active" // Physical job or training twice a day
+)
+
+// DefaultActivityLevel is assumed for users who did not set one
+const DefaultActivityLevel = Sedentary
+
+var activityFactors = map[string]float64{
+	Sedentary:  1.2,
+	Light:      1.375,
+	Moderate:   1.55,
+	Active:     1.725,
+	VeryActive: 1.9,
+}
+
+var (
+	ErrUnknownFormula  = errors.New("unknown BMR formula")
+	ErrBodyFatRequired = errors.New("body fat percentage is required")
+)
+
+// Profile holds the body data the formulas are based on
+type Profile struct {
+	Male     bool
+	HeightCm float64
+	WeightKg float64
+	Age      int
+	BodyFat  float64 // Percentage, 0 if unknown
+}
+
+// ValidFormula reports whether formula is one of the supported formulas
+func ValidFormula(formula string) bool {
+	switch formula {
+	case MifflinStJeor, HarrisBenedict, KatchMcArdle:
+		return true
+	}
+	return false
+}
+
+// ActivityFactor returns the TDEE multiplier of an activity level
+func ActivityFactor(level string) (float64, bool) {
+	f, ok := activityFactors[level]
+	return f, ok
+}
+
+// BMR estimates the basal metabolic rate in kcal per day
+func BMR(formula string, p Profile) (float64, error) {
+	w, h, a := p.WeightKg, p.HeightCm, float64(p.Age)
+	switch formula {
+	case MifflinStJeor:
+		bmr := 10*w + 6.25*h - 5*a
+		if p.Male {
+			return bmr + 5, nil
+		}
+		return bmr - 161, nil
+	case HarrisBenedict:
+		if p.Male {
+			return 88.362 + 13.397*w + 4.799*h - 5.677*a, nil
+		}
+		return 447.593 + 9.247*w + 3.098*h - 4.330*a, nil
+	case KatchMcArdle:
+		if p.BodyFat <= 0 {
+			return 0, ErrBodyFatRequired
+		}
+		leanMass := w * (1 - p.BodyFat/100)
+		return 370 + 21.6*leanMass, nil
+	}
+	return 0, fmt.Errorf("%w: %q", ErrUnknownFormula, formula)
+}
+
+// TDEE estimates the total daily energy expenditure, the calories needed to maintain weight
+func TDEE(bmr float64, level string) (float64, bool) {
+	f, ok := ActivityFactor(level)
+	if !ok {
+		return 0, false
+	}
+	return bmr * f, true
+}
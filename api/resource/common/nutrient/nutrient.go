@@ -0,0 +1,43 @@
+package nutrient
+
+import "github.com/shopspring/decimal"
+
+// Rounding rules shared by all resources:
+//   - values are stored as NUMERIC(10,2) and computed at full precision,
+//     rounded half away from zero to StoragePlaces only when persisted;
+//   - JSON DTOs expose energy as whole kcal and macros with one decimal place.
+const (
+	StoragePlaces  = 2
+	KcalDTOPlaces  = 0
+	MacroDTOPlaces = 1
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// Round rounds a computed value to the stored precision
+func Round(d decimal.Decimal) decimal.Decimal {
+	return d.Round(StoragePlaces)
+}
+
+// Scale converts a per-100g value to the given amount in grams, rounded for storage
+func Scale(per100g decimal.Decimal, grams decimal.Decimal) decimal.Decimal {
+	return Round(per100g.Mul(grams).Div(hundred))
+}
+
+// Per100g converts a value given for an amount in grams to its per-100g value, rounded for storage
+func Per100g(value decimal.Decimal, grams decimal.Decimal) decimal.Decimal {
+	if grams.IsZero() {
+		return decimal.Zero
+	}
+	return Round(value.Mul(hundred).Div(grams))
+}
+
+// Kcal formats an energy value for JSON DTOs
+func Kcal(d decimal.Decimal) float64 {
+	return d.Round(KcalDTOPlaces).InexactFloat64()
+}
+
+// Macro formats a macronutrient value in grams for JSON DTOs
+func Macro(d decimal.Decimal) float64 {
+	return d.Round(MacroDTOPlaces).InexactFloat64()
+}
@@ -0,0 +1,207 @@
+package nutrient
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Units nutrient amounts are expressed in
+const (
+	UnitGrams      = "g"
+	UnitMilligrams = "mg"
+	UnitMicrograms = "µg"
+)
+
+// Codes of the extended nutrients, used as keys of a Set
+const (
+	Fiber        = "fiber"
+	Sugars       = "sugars"
+	SaturatedFat = "saturated_fat"
+	TransFat     = "trans_fat"
+	Salt         = "salt"
+	Sodium       = "sodium"
+	Cholesterol  = "cholesterol"
+	Potassium    = "potassium"
+	Calcium      = "calcium"
+	Iron         = "iron"
+	Magnesium    = "magnesium"
+	VitaminA     = "vitamin_a"
+	VitaminC     = "vitamin_c"
+	VitaminD     = "vitamin_d"
+	VitaminB12   = "vitamin_b12"
+)
+
+// Definition describes a nutrient of the extended profile
+type Definition struct {
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	Unit      string `json:"unit"`
+	DTOPlaces int32  `json:"-"` // Decimal places exposed in JSON DTOs
+}
+
+// Catalog lists the supported extended nutrients. New nutrients only need an
+// entry here; values are stored as JSON objects keyed by code.
+var Catalog = []Definition{
+	{Fiber, "Fiber", UnitGrams, 1},
+	{Sugars, "Sugars", UnitGrams, 1},
+	{SaturatedFat, "Saturated fat", UnitGrams, 1},
+	{TransFat, "Trans fat", UnitGrams, 1},
+	{Salt, "Salt", UnitGrams, 2},
+	{Sodium, "Sodium", UnitMilligrams, 0},
+	{Cholesterol, "Cholesterol", UnitMilligrams, 0},
+	{Potassium, "Potassium", UnitMilligrams, 0},
+	{Calcium, "Calcium", UnitMilligrams, 0},
+	{Iron, "Iron", UnitMilligrams, 1},
+	{Magnesium, "Magnesium", UnitMilligrams, 0},
+	{VitaminA, "Vitamin A", UnitMicrograms, 0},
+	{VitaminC, "Vitamin C", UnitMilligrams, 1},
+	{VitaminD, "Vitamin D", UnitMicrograms, 1},
+	{VitaminB12, "Vitamin B12", UnitMicrograms, 1},
+}
+
+// ErrUnknownNutrient is returned when a Set contains a code missing from the Catalog
+var ErrUnknownNutrient = errors.New("unknown nutrient")
+
+// sodiumPerSaltGram is the milligrams of sodium in one gram of salt (EU labelling factor 2.5)
+var sodiumPerSaltGram = decimal.NewFromInt(400)
+
+// Lookup returns the definition of a nutrient code
+func Lookup(code string) (Definition, bool) {
+	for _, d := range Catalog {
+		if d.Code == code {
+			return d, true
+		}
+	}
+	return Definition{}, false
+}
+
+// Set holds amounts of extended nutrients keyed by code. It is stored as a JSONB column.
+type Set map[string]decimal.Decimal
+
+// Validate checks that every code is in the Catalog and no amount is negative
+func (s Set) Validate() error {
+	for code, v := range s {
+		if _, ok := Lookup(code); !ok {
+			return fmt.Errorf("%w: %q", ErrUnknownNutrient, code)
+		}
+		if v.IsNegative() {
+			return fmt.Errorf("nutrient %q cannot be negative", code)
+		}
+	}
+	return nil
+}
+
+// Complete derives sodium from salt or salt from sodium when only one of them is given
+func (s Set) Complete() Set {
+	salt, hasSalt := s[Salt]
+	sodium, hasSodium := s[Sodium]
+	switch {
+	case hasSalt && !hasSodium:
+		s[Sodium] = Round(salt.Mul(sodiumPerSaltGram))
+	case hasSodium && !hasSalt:
+		s[Salt] = Round(sodium.Div(sodiumPerSaltGram))
+	}
+	return s
+}
+
+// Scale converts per-100g amounts to the given amount in grams, rounded for storage
+func (s Set) Scale(grams decimal.Decimal) Set {
+	out := make(Set, len(s))
+	for code, v := range s {
+		out[code] = Scale(v, grams)
+	}
+	return out
+}
+
+// Per100g converts amounts given for an amount in grams to per-100g amounts, rounded for storage
+func (s Set) Per100g(grams decimal.Decimal) Set {
+	out := make(Set, len(s))
+	for code, v := range s {
+		out[code] = Per100g(v, grams)
+	}
+	return out
+}
+
+// Mul multiplies every amount by factor at full precision
+func (s Set) Mul(factor decimal.Decimal) Set {
+	out := make(Set, len(s))
+	for code, v := range s {
+		out[code] = v.Mul(factor)
+	}
+	return out
+}
+
+// Round rounds every amount to the stored precision
+func (s Set) Round() Set {
+	out := make(Set, len(s))
+	for code, v := range s {
+		out[code] = Round(v)
+	}
+	return out
+}
+
+// Add returns the sum of both sets; a nutrient missing from one side counts as zero
+func (s Set) Add(other Set) Set {
+	out := make(Set, len(s)+len(other))
+	for code, v := range s {
+		out[code] = v
+	}
+	for code, v := range other {
+		out[code] = out[code].Add(v)
+	}
+	return out
+}
+
+// ToDto rounds every amount according to its Catalog definition
+func (s Set) ToDto() map[string]float64 {
+	if len(s) == 0 {
+		return nil
+	}
+	out := make(map[string]float64, len(s))
+	for code, v := range s {
+		places := int32(MacroDTOPlaces)
+		if d, ok := Lookup(code); ok {
+			places = d.DTOPlaces
+		}
+		out[code] = v.Round(places).InexactFloat64()
+	}
+	return out
+}
+
+// Value implements driver.Valuer, storing the set as a JSON object
+func (s Set) Value() (driver.Value, error) {
+	if s == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner for JSON objects read from the database
+func (s *Set) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*s = Set{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into nutrient.Set", value)
+	}
+	set := Set{}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return err
+	}
+	*s = set
+	return nil
+}
@@ -0,0 +1,136 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Issuer is the "iss" claim of access tokens
+const Issuer = "fitapp"
+
+// Lifetimes of tokens unless configured otherwise
+const (
+	DefaultAccessTTL  = 15 * time.Minute
+	DefaultRefreshTTL = 30 * 24 * time.Hour
+)
+
+// ErrInvalidToken is returned for malformed, forged or expired tokens
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// Manager issues and verifies HS256-signed JWT access tokens and issues the
+// opaque refresh tokens stored server-side
+type Manager struct {
+	secret     []byte
+	ttl        time.Duration
+	refreshTTL time.Duration
+}
+
+// NewManager creates a token manager signing with secret. Non-positive TTLs
+// fall back to DefaultAccessTTL and DefaultRefreshTTL.
+func NewManager(secret string, ttl, refreshTTL time.Duration) *Manager {
+	if ttl <= 0 {
+		ttl = DefaultAccessTTL
+	}
+	if refreshTTL <= 0 {
+		refreshTTL = DefaultRefreshTTL
+	}
+	return &Manager{
+		secret:     []byte(secret),
+		ttl:        ttl,
+		refreshTTL: refreshTTL,
+	}
+}
+
+// TTL returns the lifetime of issued access tokens
+func (m *Manager) TTL() time.Duration {
+	return m.ttl
+}
+
+// RefreshTTL returns the lifetime of issued refresh tokens
+func (m *Manager) RefreshTTL() time.Duration {
+	return m.refreshTTL
+}
+
+// Claims identifies the user, their role and the session an access token was issued for
+type Claims struct {
+	jwt.RegisteredClaims
+	SessionID string `json:"sid"`
+	Role      string `json:"role"`
+}
+
+// Identity is the verified content of an access token
+type Identity struct {
+	UserID    uuid.UUID
+	SessionID uuid.UUID
+	Role      string // Role of the user when the token was issued
+}
+
+// Issue signs an access token for the user's session, valid from now for the manager's TTL
+func (m *Manager) Issue(userID, sessionID uuid.UUID, role string, now time.Time) (string, time.Time, error) {
+	expiresAt := now.Add(m.ttl)
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    Issuer,
+			Subject:   userID.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			ID:        uuid.NewString(),
+		},
+		SessionID: sessionID.String(),
+		Role:      role,
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// Verify checks the signature, issuer and expiry of an access token and
+// returns the identity it was issued to
+func (m *Manager) Verify(tokenString string) (*Identity, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(*jwt.Token) (interface{}, error) {
+		return m.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(Issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidToken, err)
+	}
+	identity := &Identity{Role: claims.Role}
+	if identity.UserID, err = uuid.Parse(claims.Subject); err != nil {
+		return nil, errors.Join(ErrInvalidToken, err)
+	}
+	if identity.SessionID, err = uuid.Parse(claims.SessionID); err != nil {
+		return nil, errors.Join(ErrInvalidToken, err)
+	}
+	return identity, nil
+}
+
+// NewRefreshToken generates a random opaque refresh token. Only its hash is
+// stored, the token itself is handed to the client once.
+func NewRefreshToken() (token string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken returns the hex SHA-256 of a refresh token. Tokens carry
+// 256 bits of entropy, so an unsalted fast hash is sufficient.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
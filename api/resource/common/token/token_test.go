@@ -0,0 +1,87 @@
+package token_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"fitapp-backend/api/resource/common/token"
+	testUtil "fitapp-backend/util/test"
+)
+
+const secret = "test-secret-of-at-least-32-bytes!"
+
+// sign creates a token with the claims, bypassing Manager.Issue
+func sign(t *testing.T, method jwt.SigningMethod, claims jwt.Claims, key interface{}) string {
+	t.Helper()
+	signed, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	testUtil.NoError(t, err)
+	return signed
+}
+
+func TestManager_Verify(t *testing.T) {
+	t.Parallel()
+	m := token.NewManager(secret, time.Minute, 0)
+	userID, sessionID := uuid.New(), uuid.New()
+
+	signed, expiresAt, err := m.Issue(userID, sessionID, "admin", time.Now())
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, true, expiresAt.After(time.Now()))
+
+	identity, err := m.Verify(signed)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, userID, identity.UserID)
+	testUtil.Equal(t, sessionID, identity.SessionID)
+	testUtil.Equal(t, "admin", identity.Role)
+}
+
+func TestManager_Verify_Rejected(t *testing.T) {
+	t.Parallel()
+	m := token.NewManager(secret, time.Minute, 0)
+	now := time.Now()
+	claims := func(edit func(c *token.Claims)) *token.Claims {
+		c := &token.Claims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    token.Issuer,
+				Subject:   uuid.NewString(),
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+			},
+			SessionID: uuid.NewString(),
+			Role:      "user",
+		}
+		if edit != nil {
+			edit(c)
+		}
+		return c
+	}
+	expired, _, err := token.NewManager(secret, time.Minute, 0).Issue(uuid.New(), uuid.New(), "user", now.Add(-2*time.Minute))
+	testUtil.NoError(t, err)
+
+	// The claims are valid as long as they are left unchanged
+	_, err = m.Verify(sign(t, jwt.SigningMethodHS256, claims(nil), []byte(secret)))
+	testUtil.NoError(t, err)
+
+	cases := []struct {
+		name  string
+		token string
+	}{
+		{"malformed", "not-a-token"},
+		{"wrong algorithm", sign(t, jwt.SigningMethodHS384, claims(nil), []byte(secret))},
+		{"unsigned", sign(t, jwt.SigningMethodNone, claims(nil), jwt.UnsafeAllowNoneSignatureType)},
+		{"wrong secret", sign(t, jwt.SigningMethodHS256, claims(nil), []byte("another-secret-of-at-least-32-bytes"))},
+		{"wrong issuer", sign(t, jwt.SigningMethodHS256, claims(func(c *token.Claims) { c.Issuer = "someone-else" }), []byte(secret))},
+		{"expired", expired},
+		{"no expiry", sign(t, jwt.SigningMethodHS256, claims(func(c *token.Claims) { c.ExpiresAt = nil }), []byte(secret))},
+		{"invalid subject", sign(t, jwt.SigningMethodHS256, claims(func(c *token.Claims) { c.Subject = "admin" }), []byte(secret))},
+		{"invalid session", sign(t, jwt.SigningMethodHS256, claims(func(c *token.Claims) { c.SessionID = "" }), []byte(secret))},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := m.Verify(tc.token)
+			testUtil.ErrorIs(t, err, token.ErrInvalidToken)
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package totp_test
+
+import (
+	"testing"
+	"time"
+
+	"fitapp-backend/api/resource/common/totp"
+	testUtil "fitapp-backend/util/test"
+)
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+	// RFC 6238 appendix B SHA-1 key, the codes are the last 6 of its 8 digits
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	for unix, want := range map[int64]string{59: "287082", 1111111109: "081804", 1234567890: "005924", 2000000000: "279037"} {
+		code, err := totp.Code(secret, totp.Step(time.Unix(unix, 0)))
+		testUtil.NoError(t, err)
+		testUtil.Equal(t, want, code)
+	}
+
+	// A code stays valid for one period of clock drift, but only once
+	step, ok := totp.Verify(secret, "287082", time.Unix(59+30, 0), 0)
+	testUtil.Equal(t, true, ok)
+	testUtil.Equal(t, int64(1), step)
+	_, ok = totp.Verify(secret, "287082", time.Unix(59, 0), step)
+	testUtil.Equal(t, false, ok)
+	_, ok = totp.Verify(secret, "287082", time.Unix(59+60, 0), 0)
+	testUtil.Equal(t, false, ok)
+}
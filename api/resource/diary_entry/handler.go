@@ -0,0 +1,542 @@
+package diaryentry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"fitapp-backend/api/resource/meal"
+	"fitapp-backend/api/resource/product"
+	"fitapp-backend/api/resource/recipe"
+	userday "fitapp-backend/api/resource/user_day"
+)
+
+// API holds the dependencies for the diary entry handlers
+type API struct {
+	repository        *Repository
+	productRepository *product.Repository
+	mealRepository    *meal.Repository
+	recipeRepository  *recipe.Repository
+	user_day_api      *userday.API
+}
+
+// New creates a new API instance for diary entry routes
+func New(db *gorm.DB, user_day_api *userday.API) *API {
+	return &API{
+		repository:        NewRepository(db),
+		productRepository: product.NewRepository(db),
+		mealRepository:    meal.NewRepository(db),
+		recipeRepository:  recipe.NewRepository(db),
+		user_day_api:      user_day_api,
+	}
+}
+
+func handleErr(w http.ResponseWriter, status int, message string, err error) {
+	// Log the error internally
+	fmt.Printf("ERROR [%d]: %s - %v\n", status, message, err)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+func enableCors(w *http.ResponseWriter) {
+	(*w).Header().Set("Access-Control-Allow-Origin", "*")
+}
+
+// parseDay reads the user ID and date path parameters shared by all entry routes.
+// The date "today" resolves to the current date in the user's timezone.
+func (a *API) parseDay(w http.ResponseWriter, r *http.Request) (uuid.UUID, time.Time, bool) {
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid user ID format (must be UUID)", err)
+		return uuid.Nil, time.Time{}, false
+	}
+
+	userDate, ok := a.parseDate(w, userID, "date", chi.URLParam(r, "date"))
+	if !ok {
+		return uuid.Nil, time.Time{}, false
+	}
+	return userID, userDate, true
+}
+
+// parseDate parses a date or "today" for the user.
+// Returns false if an error response has been written.
+func (a *API) parseDate(w http.ResponseWriter, userID uuid.UUID, field string, value string) (time.Time, bool) {
+	date, err := a.user_day_api.ParseDate(userID, value)
+	if err != nil {
+		userday.WriteDateErr(w, field, err)
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// parseEntryID reads the entry ID path parameter
+func parseEntryID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "entryId"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid entry ID format (must be UUID)", err)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// fillFromForm validates the form and fills the entry with the product or
+// recipe snapshot. Returns false if an error response has already been written.
+func (a *API) fillFromForm(w http.ResponseWriter, form *Form, entry *DiaryEntry) bool {
+	if (form.ProductID == "") == (form.RecipeID == "") {
+		handleErr(w, http.StatusUnprocessableEntity, "Exactly one of product_id or recipe_id is required", nil)
+		return false
+	}
+
+	mealName := meal.NormalizeName(form.Meal)
+	if mealName == "" {
+		mealName = meal.Snacks
+	}
+	if !meal.IsStandard(mealName) {
+		exists, err := a.mealRepository.Exists(entry.UserID, mealName)
+		if err != nil {
+			handleErr(w, http.StatusInternalServerError, "Failed to check meal", err)
+			return false
+		}
+		if !exists {
+			handleErr(w, http.StatusUnprocessableEntity, "Unknown meal", nil)
+			return false
+		}
+	}
+
+	if entry.LoggedAt.IsZero() {
+		entry.LoggedAt = time.Now() // New entries only, updates keep their logged time
+	}
+	if form.LoggedAt != "" {
+		loggedAt, err := time.Parse(time.RFC3339, form.LoggedAt)
+		if err != nil {
+			handleErr(w, http.StatusBadRequest, "Invalid logged_at format (must be RFC3339)", err)
+			return false
+		}
+		entry.LoggedAt = loggedAt
+	}
+	entry.Meal = mealName
+
+	if form.RecipeID != "" {
+		return a.fillFromRecipe(w, form, entry)
+	}
+	return a.fillFromProduct(w, form, entry)
+}
+
+// fillFromProduct snapshots the referenced product scaled to the form's grams
+// or to a quantity of one of the product's named servings
+func (a *API) fillFromProduct(w http.ResponseWriter, form *Form, entry *DiaryEntry) bool {
+	productID, err := uuid.Parse(form.ProductID)
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid product_id format (must be UUID)", err)
+		return false
+	}
+
+	if form.Serving == "" && form.Grams <= 0 {
+		handleErr(w, http.StatusUnprocessableEntity, "Grams must be greater than zero", nil)
+		return false
+	}
+	if form.Serving != "" && (form.Grams > 0 || form.Quantity <= 0) {
+		handleErr(w, http.StatusUnprocessableEntity, "A serving requires a quantity greater than zero and no grams", nil)
+		return false
+	}
+
+	p, err := a.productRepository.Read(productID)
+	if err == nil && !p.VisibleTo(entry.UserID) {
+		err = gorm.ErrRecordNotFound // Other users' private products cannot be logged
+	}
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			handleErr(w, http.StatusUnprocessableEntity, "Product not found", err)
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to read product", err)
+		}
+		return false
+	}
+
+	entry.Grams = form.Grams
+	if form.Serving != "" {
+		serving, err := a.productRepository.FindServing(productID, product.NormalizeServingName(form.Serving))
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				handleErr(w, http.StatusUnprocessableEntity, "Unknown serving for this product", err)
+			} else {
+				handleErr(w, http.StatusInternalServerError, "Failed to read serving", err)
+			}
+			return false
+		}
+		servingGrams, err := p.ToGrams(serving.Amount, serving.Unit)
+		if err != nil {
+			handleErr(w, http.StatusUnprocessableEntity, err.Error(), err)
+			return false
+		}
+		entry.Grams = int(math.Round(form.Quantity * servingGrams))
+	}
+	entry.applyProduct(p)
+	return true
+}
+
+// fillFromRecipe snapshots the referenced recipe scaled to the form's grams or servings
+func (a *API) fillFromRecipe(w http.ResponseWriter, form *Form, entry *DiaryEntry) bool {
+	recipeID, err := uuid.Parse(form.RecipeID)
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid recipe_id format (must be UUID)", err)
+		return false
+	}
+
+	if (form.Grams > 0) == (form.Servings > 0) {
+		handleErr(w, http.StatusUnprocessableEntity, "Exactly one of grams or servings must be greater than zero", nil)
+		return false
+	}
+
+	rc, err := a.recipeRepository.Read(recipeID)
+	if err == nil && rc.UserID != entry.UserID {
+		err = gorm.ErrRecordNotFound // Other users' recipes cannot be logged
+	}
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			handleErr(w, http.StatusUnprocessableEntity, "Recipe not found", err)
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to read recipe", err)
+		}
+		return false
+	}
+
+	entry.Grams = form.Grams
+	if form.Servings > 0 {
+		entry.Grams = int(math.Round(form.Servings * rc.ServingWeight()))
+	}
+	entry.applyRecipe(rc)
+	return true
+}
+
+// List godoc
+//
+//	@summary		List diary entries
+//	@description	List all food entries logged by the user on the given date
+//	@tags			diary-entries
+//	@accept			json
+//	@produce		json
+//	@param			id		path		string	true	"User ID (UUID)"
+//	@param			date	path		string	true	"Date (YYYY-MM-DD or today)"
+//	@success		200	{array}		DTO
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/days/{date}/entries [get]
+func (a *API) List(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	userID, userDate, ok := a.parseDay(w, r)
+	if !ok {
+		return
+	}
+
+	entries, err := a.repository.List(userID, userDate)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to retrieve diary entries", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if len(entries) == 0 {
+		fmt.Fprint(w, "[]")
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(entries.ToDto()); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode response", err)
+		return
+	}
+}
+
+// Create godoc
+//
+//	@summary		Create diary entry
+//	@description	Log a product or recipe for the user on the given date and update the day's totals
+//	@tags			diary-entries
+//	@accept			json
+//	@produce		json
+//	@param			id		path	string	true	"User ID (UUID)"
+//	@param			date	path	string	true	"Date (YYYY-MM-DD or today)"
+//	@param			body	body	Form	true	"Diary entry form"
+//	@success		201	{object}	DTO "Returns the created entry"
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		422	{object}	map[string]string "Unprocessable Entity"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/days/{date}/entries [post]
+func (a *API) Create(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	userID, userDate, ok := a.parseDay(w, r)
+	if !ok {
+		return
+	}
+
+	form := &Form{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid request body JSON", err)
+		return
+	}
+
+	newEntry := &DiaryEntry{
+		ID:       uuid.New(),
+		UserID:   userID,
+		UserDate: userDate,
+	}
+	if !a.fillFromForm(w, form, newEntry) {
+		return
+	}
+
+	err := a.repository.WriteDay(userID, userDate, func(tx *Repository) error {
+		_, err := tx.Create(newEntry)
+		return err
+	})
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to create diary entry", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(newEntry.ToDto()); err != nil {
+		fmt.Printf("Error encoding created diary entry response: %v\n", err)
+	}
+}
+
+// Read godoc
+//
+//	@summary		Read diary entry
+//	@description	Read a single food entry of the user's day
+//	@tags			diary-entries
+//	@accept			json
+//	@produce		json
+//	@param			id		path		string	true	"User ID (UUID)"
+//	@param			date	path		string	true	"Date (YYYY-MM-DD or today)"
+//	@param			entryId	path		string	true	"Entry ID (UUID)"
+//	@success		200	{object}	DTO
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/days/{date}/entries/{entryId} [get]
+func (a *API) Read(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	userID, userDate, ok := a.parseDay(w, r)
+	if !ok {
+		return
+	}
+	id, ok := parseEntryID(w, r)
+	if !ok {
+		return
+	}
+
+	entry, err := a.repository.Read(userID, userDate, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			handleErr(w, http.StatusNotFound, "Diary entry not found", err)
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to read diary entry", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(entry.ToDto()); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode response", err)
+		return
+	}
+}
+
+// Update godoc
+//
+//	@summary		Update diary entry
+//	@description	Change the product, recipe or amount of a logged entry and update the day's totals
+//	@tags			diary-entries
+//	@accept			json
+//	@produce		json
+//	@param			id		path		string	true	"User ID (UUID)"
+//	@param			date	path		string	true	"Date (YYYY-MM-DD or today)"
+//	@param			entryId	path		string	true	"Entry ID (UUID)"
+//	@param			body	body		Form	true	"Diary entry form"
+//	@success		200	{object}	DTO "Returns the updated entry"
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		422	{object}	map[string]string "Unprocessable Entity"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/days/{date}/entries/{entryId} [put]
+func (a *API) Update(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	userID, userDate, ok := a.parseDay(w, r)
+	if !ok {
+		return
+	}
+	id, ok := parseEntryID(w, r)
+	if !ok {
+		return
+	}
+
+	form := &Form{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid request body JSON", err)
+		return
+	}
+
+	entry, err := a.repository.Read(userID, userDate, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			handleErr(w, http.StatusNotFound, "Diary entry not found", err)
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to read diary entry", err)
+		}
+		return
+	}
+
+	if !a.fillFromForm(w, form, entry) {
+		return
+	}
+
+	err = a.repository.WriteDay(userID, userDate, func(tx *Repository) error {
+		_, err := tx.Update(entry)
+		return err
+	})
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to update diary entry", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(entry.ToDto())
+}
+
+// Delete godoc
+//
+//	@summary		Delete diary entry
+//	@description	Soft delete a logged entry and lower the day's totals accordingly
+//	@tags			diary-entries
+//	@accept			json
+//	@produce		json
+//	@param			id		path	string	true	"User ID (UUID)"
+//	@param			date	path	string	true	"Date (YYYY-MM-DD or today)"
+//	@param			entryId	path	string	true	"Entry ID (UUID)"
+//	@success		200	{object}	map[string]string "Successfully deleted"
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/days/{date}/entries/{entryId} [delete]
+func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	userID, userDate, ok := a.parseDay(w, r)
+	if !ok {
+		return
+	}
+	id, ok := parseEntryID(w, r)
+	if !ok {
+		return
+	}
+
+	err := a.repository.WriteDay(userID, userDate, func(tx *Repository) error {
+		rowsAffected, err := tx.Delete(userID, userDate, id)
+		if err == nil && rowsAffected == 0 {
+			err = gorm.ErrRecordNotFound // Rolls back, leaving the totals untouched
+		}
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// GORM soft delete returns 0 rows affected if already deleted or not found
+			handleErr(w, http.StatusNotFound, "Diary entry not found or already deleted", nil)
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to delete diary entry", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Diary entry deleted successfully"})
+}
+
+// Copy godoc
+//
+//	@summary		Copy diary entries
+//	@description	Copy all entries (or those of one meal) logged on from_date to the date in the path
+//	@description	and update the day's totals. Entries keep their nutritional snapshot and time of day.
+//	@description	With dry_run the entries that would be added are returned without saving them.
+//	@tags			diary-entries
+//	@accept			json
+//	@produce		json
+//	@param			id		path		string		true	"User ID (UUID)"
+//	@param			date	path		string		true	"Target date (YYYY-MM-DD or today)"
+//	@param			body	body		CopyForm	true	"Copy form"
+//	@success		201	{object}	CopyDTO "Returns the added entries"
+//	@success		200	{object}	CopyDTO "Dry run, returns the entries that would be added"
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		422	{object}	map[string]string "Unprocessable Entity"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/days/{date}/copy [post]
+func (a *API) Copy(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	userID, userDate, ok := a.parseDay(w, r)
+	if !ok {
+		return
+	}
+
+	form := &CopyForm{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid request body JSON", err)
+		return
+	}
+
+	fromDate, ok := a.parseDate(w, userID, "from_date", form.FromDate)
+	if !ok {
+		return
+	}
+	if fromDate.Equal(userDate) {
+		handleErr(w, http.StatusUnprocessableEntity, "from_date must differ from the target date", nil)
+		return
+	}
+
+	entries, err := a.repository.List(userID, fromDate)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to retrieve diary entries", err)
+		return
+	}
+	if mealName := meal.NormalizeName(form.Meal); mealName != "" {
+		selected := make(DiaryEntries, 0, len(entries))
+		for _, e := range entries {
+			if e.Meal == mealName {
+				selected = append(selected, e)
+			}
+		}
+		entries = selected
+	}
+	if len(entries) == 0 {
+		handleErr(w, http.StatusNotFound, "No diary entries to copy", nil)
+		return
+	}
+
+	copies := entries.CopyTo(userDate)
+	status := http.StatusOK
+	if !form.DryRun {
+		err := a.repository.WriteDay(userID, userDate, func(tx *Repository) error {
+			return tx.CreateBatch(copies)
+		})
+		if err != nil {
+			handleErr(w, http.StatusInternalServerError, "Failed to copy diary entries", err)
+			return
+		}
+		status = http.StatusCreated
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(copies.ToCopyDto(form.DryRun)); err != nil {
+		fmt.Printf("Error encoding copied diary entries response: %v\n", err)
+	}
+}
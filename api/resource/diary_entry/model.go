@@ -0,0 +1,95 @@
+package diaryentry
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"fitapp-backend/api/resource/common/nutrient"
+)
+
+// DiaryEntry represents a single logged food item in the 'diary_entries' table.
+// Exactly one of ProductID and RecipeID is set. Nutritional values are a snapshot
+// of the product or recipe at logging time, scaled to Grams.
+type DiaryEntry struct {
+	ID        uuid.UUID `gorm:"type:uuid;primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	UserID    uuid.UUID       `gorm:"type:uuid;not null;index:idx_diary_entry_user_date"`
+	UserDate  time.Time       `gorm:"type:date;not null;index:idx_diary_entry_user_date"`
+	ProductID *uuid.UUID      `gorm:"type:uuid"`
+	RecipeID  *uuid.UUID      `gorm:"type:uuid"`
+	Meal      string          `gorm:"not null"` // Standard slot or a user's custom meal name
+	Grams     int             `gorm:"not null"`
+	Kcal      decimal.Decimal `gorm:"type:numeric(10,2);not null"`
+	Proteins  decimal.Decimal `gorm:"type:numeric(10,2);not null"`
+	Carbs     decimal.Decimal `gorm:"type:numeric(10,2);not null"`
+	Fats      decimal.Decimal `gorm:"type:numeric(10,2);not null"`
+	Nutrients nutrient.Set    `gorm:"type:jsonb;not null"` // extended profile
+	LoggedAt  time.Time       `gorm:"not null"`
+}
+
+// DiaryEntries is a slice of DiaryEntry pointers
+type DiaryEntries []*DiaryEntry
+
+// Totals holds the summed nutritional values of a user's day
+type Totals struct {
+	Kcal      decimal.Decimal
+	Proteins  decimal.Decimal
+	Carbs     decimal.Decimal
+	Fats      decimal.Decimal
+	Nutrients nutrient.Set `gorm:"-"` // Summed separately, see Repository.Totals
+}
+
+// DTO represents the data transfer object for a DiaryEntry
+type DTO struct {
+	ID        string             `json:"id"`
+	UserID    string             `json:"user_id"`
+	UserDate  string             `json:"user_date"` // Format "YYYY-MM-DD"
+	ProductID string             `json:"product_id,omitempty"`
+	RecipeID  string             `json:"recipe_id,omitempty"`
+	Meal      string             `json:"meal"`
+	Grams     int                `json:"grams"`
+	Kcal      float64            `json:"kcal"`
+	Proteins  float64            `json:"proteins"`
+	Carbs     float64            `json:"carbs"`
+	Fats      float64            `json:"fats"`
+	Nutrients map[string]float64 `json:"nutrients,omitempty"`
+	LoggedAt  string             `json:"logged_at"` // RFC3339
+}
+
+// Form represents the data structure for creating/updating a DiaryEntry.
+// UserID and UserDate come from the URL path.
+type Form struct {
+	ProductID string  `json:"product_id"` // Either product_id or recipe_id is required
+	RecipeID  string  `json:"recipe_id"`
+	Meal      string  `json:"meal"` // Optional, defaults to "snacks"
+	Grams     int     `json:"grams"`
+	Servings  float64 `json:"servings"` // Recipes only, alternative to grams
+	Serving   string  `json:"serving"`  // Products only, named serving used with quantity instead of grams
+	Quantity  float64 `json:"quantity"`
+	LoggedAt  string  `json:"logged_at"` // Optional, RFC3339; defaults to now, updates keep the logged time
+}
+
+// CopyForm represents the request to copy the entries of another date to the
+// date in the URL path
+type CopyForm struct {
+	FromDate string `json:"from_date"` // Format "YYYY-MM-DD" or "today", required
+	Meal     string `json:"meal"`      // Optional, copies only this meal
+	DryRun   bool   `json:"dry_run"`   // Returns the entries that would be added without saving them
+}
+
+// CopyDTO describes the entries added by a copy and their summed values
+type CopyDTO struct {
+	DryRun    bool               `json:"dry_run"`
+	Entries   []*DTO             `json:"entries"`
+	Kcal      float64            `json:"kcal"`
+	Proteins  float64            `json:"proteins"`
+	Carbs     float64            `json:"carbs"`
+	Fats      float64            `json:"fats"`
+	Nutrients map[string]float64 `json:"nutrients,omitempty"`
+}
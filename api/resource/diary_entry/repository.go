@@ -0,0 +1,133 @@
+package diaryentry
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"fitapp-backend/api/resource/common/nutrient"
+	userday "fitapp-backend/api/resource/user_day"
+)
+
+// Repository handles database operations for diary_entries
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new diary entry repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// List retrieves all non-deleted entries of a user's day ordered by logging time
+func (r *Repository) List(userID uuid.UUID, date time.Time) (DiaryEntries, error) {
+	entries := make([]*DiaryEntry, 0)
+	dateStr := date.Format(userday.DateFormat)
+	if err := r.db.Where("user_id = ? AND user_date = ?", userID, dateStr).Order("logged_at").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Create inserts a new diary entry into the database
+func (r *Repository) Create(entry *DiaryEntry) (*DiaryEntry, error) {
+	if err := r.db.Create(entry).Error; err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// CreateBatch inserts several diary entries in a single statement
+func (r *Repository) CreateBatch(entries DiaryEntries) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return r.db.Create(&entries).Error
+}
+
+// Read retrieves a single entry by its ID, scoped to the user's day
+func (r *Repository) Read(userID uuid.UUID, date time.Time, id uuid.UUID) (*DiaryEntry, error) {
+	entry := &DiaryEntry{}
+	dateStr := date.Format(userday.DateFormat)
+	if err := r.db.Where("id = ? AND user_id = ? AND user_date = ?", id, userID, dateStr).First(&entry).Error; err != nil {
+		return nil, err // Can be gorm.ErrRecordNotFound
+	}
+	return entry, nil
+}
+
+// Update modifies the product, meal, amount and nutritional snapshot of an existing entry
+func (r *Repository) Update(entry *DiaryEntry) (int64, error) {
+	result := r.db.Model(&DiaryEntry{}).
+		Select("ProductID", "RecipeID", "Meal", "Grams", "Kcal", "Proteins", "Carbs", "Fats", "Nutrients", "LoggedAt", "UpdatedAt").
+		Where("id = ?", entry.ID).
+		Updates(entry)
+
+	return result.RowsAffected, result.Error
+}
+
+// Delete performs a soft delete on an entry, scoped to the user's day
+func (r *Repository) Delete(userID uuid.UUID, date time.Time, id uuid.UUID) (int64, error) {
+	dateStr := date.Format(userday.DateFormat)
+	result := r.db.Where("id = ? AND user_id = ? AND user_date = ?", id, userID, dateStr).Delete(&DiaryEntry{})
+	return result.RowsAffected, result.Error
+}
+
+// Totals sums the nutritional values of all non-deleted entries of a user's day
+func (r *Repository) Totals(userID uuid.UUID, date time.Time) (*Totals, error) {
+	totals := &Totals{}
+	dateStr := date.Format(userday.DateFormat)
+	err := r.db.Model(&DiaryEntry{}).
+		Select("COALESCE(SUM(kcal), 0) AS kcal, COALESCE(SUM(proteins), 0) AS proteins, COALESCE(SUM(carbs), 0) AS carbs, COALESCE(SUM(fats), 0) AS fats").
+		Where("user_id = ? AND user_date = ?", userID, dateStr).
+		Scan(totals).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Extended nutrients are JSON objects with a varying set of keys, summed here
+	var sets []nutrient.Set
+	err = r.db.Model(&DiaryEntry{}).
+		Where("user_id = ? AND user_date = ?", userID, dateStr).
+		Pluck("nutrients", &sets).Error
+	if err != nil {
+		return nil, err
+	}
+	totals.Nutrients = nutrient.Set{}
+	for _, set := range sets {
+		totals.Nutrients = totals.Nutrients.Add(set)
+	}
+	return totals, nil
+}
+
+// WriteDay runs write in a transaction holding the lock of the user's day, then
+// derives the day's totals from its entries before committing. Holding the lock
+// keeps concurrent writes from storing the totals of an older state.
+func (r *Repository) WriteDay(userID uuid.UUID, date time.Time, write func(tx *Repository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		days := userday.NewRepository(tx)
+		userDay, err := days.Lock(userID, date)
+		if err != nil {
+			return err
+		}
+
+		repo := NewRepository(tx)
+		if err := write(repo); err != nil {
+			return err
+		}
+
+		totals, err := repo.Totals(userID, date)
+		if err != nil {
+			return err
+		}
+		userDay.DailyKcal = totals.Kcal
+		userDay.DailyProteins = totals.Proteins
+		userDay.DailyCarbs = totals.Carbs
+		userDay.DailyFats = totals.Fats
+		userDay.Nutrients = totals.Nutrients
+		_, err = days.Update(userDay)
+		return err
+	})
+}
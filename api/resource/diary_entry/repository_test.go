@@ -0,0 +1,194 @@
+package diaryentry_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"fitapp-backend/api/resource/common/nutrient"
+	diaryentry "fitapp-backend/api/resource/diary_entry"
+	mockDB "fitapp-backend/mock/db"
+	testUtil "fitapp-backend/util/test"
+)
+
+var entryColumns = []string{"id", "created_at", "updated_at", "deleted_at", "user_id", "user_date", "product_id", "recipe_id", "meal", "grams", "kcal", "proteins", "carbs", "fats", "logged_at"}
+
+func getTestDate(t *testing.T) time.Time {
+	t.Helper()
+	date, err := time.Parse("2006-01-02", "2024-03-15")
+	testUtil.NoError(t, err)
+	return date
+}
+
+func TestRepository_List(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := diaryentry.NewRepository(db)
+
+	userID := uuid.New()
+	testDate := getTestDate(t)
+
+	mockRows := sqlmock.NewRows(entryColumns).
+		AddRow(uuid.New(), time.Now(), time.Now(), gorm.DeletedAt{}, userID, testDate, uuid.New(), nil, "breakfast", 150, 300, 20, 30, 10, time.Now()).
+		AddRow(uuid.New(), time.Now(), time.Now(), gorm.DeletedAt{}, userID, testDate, uuid.New(), nil, "snacks", 50, 100, 5, 10, 3, time.Now())
+
+	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "diary_entries" WHERE (user_id = $1 AND user_date = $2) AND "diary_entries"."deleted_at" IS NULL ORDER BY logged_at`)
+	mock.ExpectQuery(expectedSQL).WithArgs(userID, "2024-03-15").WillReturnRows(mockRows)
+
+	entries, err := repo.List(userID, testDate)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 2, len(entries))
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Read_NotFound(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := diaryentry.NewRepository(db)
+
+	id := uuid.New()
+	userID := uuid.New()
+	testDate := getTestDate(t)
+
+	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "diary_entries" WHERE (id = $1 AND user_id = $2 AND user_date = $3) AND "diary_entries"."deleted_at" IS NULL ORDER BY "diary_entries"."id" LIMIT $4`)
+	mock.ExpectQuery(expectedSQL).WithArgs(id, userID, "2024-03-15", 1).WillReturnError(gorm.ErrRecordNotFound)
+
+	_, err = repo.Read(userID, testDate, id)
+	testUtil.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Delete(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := diaryentry.NewRepository(db)
+
+	id := uuid.New()
+	userID := uuid.New()
+	testDate := getTestDate(t)
+
+	mock.ExpectBegin()
+	expectedSQL := regexp.QuoteMeta(`UPDATE "diary_entries" SET "deleted_at"=$1 WHERE (id = $2 AND user_id = $3 AND user_date = $4) AND "diary_entries"."deleted_at" IS NULL`)
+	mock.ExpectExec(expectedSQL).
+		WithArgs(mockDB.AnyTime{}, id, userID, "2024-03-15").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	rowsAffected, err := repo.Delete(userID, testDate, id)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, int64(1), rowsAffected)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Totals(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := diaryentry.NewRepository(db)
+
+	userID := uuid.New()
+	testDate := getTestDate(t)
+
+	mockRows := sqlmock.NewRows([]string{"kcal", "proteins", "carbs", "fats"}).AddRow("400.50", "25.25", 40, 13)
+
+	expectedSQL := regexp.QuoteMeta(`SELECT COALESCE(SUM(kcal), 0) AS kcal, COALESCE(SUM(proteins), 0) AS proteins, COALESCE(SUM(carbs), 0) AS carbs, COALESCE(SUM(fats), 0) AS fats FROM "diary_entries" WHERE (user_id = $1 AND user_date = $2) AND "diary_entries"."deleted_at" IS NULL`)
+	mock.ExpectQuery(expectedSQL).WithArgs(userID, "2024-03-15").WillReturnRows(mockRows)
+
+	nutrientRows := sqlmock.NewRows([]string{"nutrients"}).
+		AddRow(`{"fiber":"3.5","sodium":"120"}`).
+		AddRow(`{"fiber":"1.25"}`)
+	expectedSQL = regexp.QuoteMeta(`SELECT "nutrients" FROM "diary_entries" WHERE (user_id = $1 AND user_date = $2) AND "diary_entries"."deleted_at" IS NULL`)
+	mock.ExpectQuery(expectedSQL).WithArgs(userID, "2024-03-15").WillReturnRows(nutrientRows)
+
+	totals, err := repo.Totals(userID, testDate)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, "400.5", totals.Kcal.String())
+	testUtil.Equal(t, "25.25", totals.Proteins.String())
+	testUtil.Equal(t, "13", totals.Fats.String())
+	testUtil.Equal(t, "4.75", totals.Nutrients[nutrient.Fiber].String())
+	testUtil.Equal(t, "120", totals.Nutrients[nutrient.Sodium].String())
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_CreateBatch_Copy(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := diaryentry.NewRepository(db)
+
+	userID, productID := uuid.New(), uuid.New()
+	fromDate := getTestDate(t)
+	toDate := fromDate.AddDate(0, 0, 3)
+	loggedAt := time.Date(2024, 3, 15, 7, 30, 0, 0, time.UTC)
+	source := diaryentry.DiaryEntries{{
+		ID:        uuid.New(),
+		UserID:    userID,
+		UserDate:  fromDate,
+		ProductID: &productID,
+		Meal:      "breakfast",
+		Grams:     80,
+		Kcal:      decimal.RequireFromString("303.2"),
+		Proteins:  decimal.RequireFromString("10.56"),
+		Carbs:     decimal.RequireFromString("54.16"),
+		Fats:      decimal.RequireFromString("5.2"),
+		Nutrients: nutrient.Set{nutrient.Fiber: decimal.RequireFromString("8")},
+		LoggedAt:  loggedAt,
+	}}
+
+	copies := source.CopyTo(toDate)
+	testUtil.Equal(t, 1, len(copies))
+	testUtil.Equal(t, true, copies[0].ID != source[0].ID)
+	testUtil.Equal(t, toDate, copies[0].UserDate)
+	testUtil.Equal(t, time.Date(2024, 3, 18, 7, 30, 0, 0, time.UTC), copies[0].LoggedAt)
+
+	mock.ExpectBegin()
+	expectedSQL := regexp.QuoteMeta(`INSERT INTO "diary_entries" ("id","created_at","updated_at","deleted_at","user_id","user_date","product_id","recipe_id","meal","grams","kcal","proteins","carbs","fats","nutrients","logged_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16)`)
+	mock.ExpectExec(expectedSQL).
+		WithArgs(copies[0].ID, mockDB.AnyTime{}, mockDB.AnyTime{}, nil, userID, toDate, productID, nil, "breakfast", 80,
+			"303.2", "10.56", "54.16", "5.2", `{"fiber":"8"}`, copies[0].LoggedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	testUtil.NoError(t, repo.CreateBatch(copies))
+
+	dto := copies.ToCopyDto(false)
+	testUtil.Equal(t, 303.0, dto.Kcal)
+	testUtil.Equal(t, 10.6, dto.Proteins)
+	testUtil.Equal(t, 8.0, dto.Nutrients[nutrient.Fiber])
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_WriteDay_Rollback(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := diaryentry.NewRepository(db)
+
+	userID := uuid.New()
+	dayID := uuid.New()
+	testDate := getTestDate(t)
+
+	mock.ExpectBegin()
+	// The day is created if missing, then locked before any entry is written
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "user_days" ("id","created_at","updated_at","deleted_at","user_id","user_date","daily_kcal","daily_proteins","daily_carbs","daily_fats","nutrients") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) ON CONFLICT ("user_id","user_date") WHERE deleted_at IS NULL DO NOTHING`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	dayRows := sqlmock.NewRows([]string{"id", "user_id", "user_date"}).AddRow(dayID, userID, testDate)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "user_days" WHERE (user_id = $1 AND user_date = $2) AND "user_days"."deleted_at" IS NULL ORDER BY "user_days"."id" LIMIT $3 FOR UPDATE`)).
+		WithArgs(userID, "2024-03-15", 1).
+		WillReturnRows(dayRows)
+	mock.ExpectRollback()
+
+	err = repo.WriteDay(userID, testDate, func(tx *diaryentry.Repository) error {
+		return gorm.ErrRecordNotFound // A failed write leaves the totals untouched
+	})
+	testUtil.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,125 @@
+package diaryentry
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"fitapp-backend/api/resource/common/nutrient"
+	"fitapp-backend/api/resource/product"
+	"fitapp-backend/api/resource/recipe"
+	userday "fitapp-backend/api/resource/user_day"
+)
+
+// ToDto converts a DiaryEntry model to its DTO representation
+func (e *DiaryEntry) ToDto() *DTO {
+	return &DTO{
+		ID:        e.ID.String(),
+		UserID:    e.UserID.String(),
+		UserDate:  e.UserDate.Format(userday.DateFormat),
+		ProductID: uuidString(e.ProductID),
+		RecipeID:  uuidString(e.RecipeID),
+		Meal:      e.Meal,
+		Grams:     e.Grams,
+		Kcal:      nutrient.Kcal(e.Kcal),
+		Proteins:  nutrient.Macro(e.Proteins),
+		Carbs:     nutrient.Macro(e.Carbs),
+		Fats:      nutrient.Macro(e.Fats),
+		Nutrients: e.Nutrients.ToDto(),
+		LoggedAt:  e.LoggedAt.Format(time.RFC3339),
+	}
+}
+
+// ToDto converts a slice of DiaryEntry models to a slice of DTOs
+func (es DiaryEntries) ToDto() []*DTO {
+	dtos := make([]*DTO, len(es))
+	for i, e := range es {
+		dtos[i] = e.ToDto()
+	}
+	return dtos
+}
+
+// uuidString formats an optional UUID, returning an empty string for nil
+func uuidString(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+// applyProduct snapshots the product's per-100g values scaled to the entry's grams
+func (e *DiaryEntry) applyProduct(p *product.Product) {
+	e.ProductID = &p.ID
+	e.RecipeID = nil
+	grams := decimal.NewFromInt(int64(e.Grams))
+	e.Kcal = nutrient.Scale(p.Kcal, grams)
+	e.Proteins = nutrient.Scale(p.Proteins, grams)
+	e.Carbs = nutrient.Scale(p.Carbs, grams)
+	e.Fats = nutrient.Scale(p.Fats, grams)
+	e.Nutrients = p.Nutrients.Scale(grams)
+}
+
+// applyRecipe snapshots the recipe's nutrition scaled to the entry's grams
+func (e *DiaryEntry) applyRecipe(rc *recipe.Recipe) {
+	n := rc.ForGrams(e.Grams)
+	e.ProductID = nil
+	e.RecipeID = &rc.ID
+	e.Kcal = n.Kcal
+	e.Proteins = n.Proteins
+	e.Carbs = n.Carbs
+	e.Fats = n.Fats
+	e.Nutrients = n.Nutrients
+}
+
+// Totals sums the nutritional values of the entries
+func (es DiaryEntries) Totals() *Totals {
+	t := &Totals{Nutrients: nutrient.Set{}}
+	for _, e := range es {
+		t.Kcal = t.Kcal.Add(e.Kcal)
+		t.Proteins = t.Proteins.Add(e.Proteins)
+		t.Carbs = t.Carbs.Add(e.Carbs)
+		t.Fats = t.Fats.Add(e.Fats)
+		t.Nutrients = t.Nutrients.Add(e.Nutrients)
+	}
+	return t
+}
+
+// CopyTo returns new entries with the same food, amounts and nutritional snapshot
+// logged on the target date. Logging times keep their time of day.
+func (es DiaryEntries) CopyTo(userDate time.Time) DiaryEntries {
+	copies := make(DiaryEntries, len(es))
+	for i, e := range es {
+		days := int(userDate.Sub(e.UserDate).Hours() / 24)
+		copies[i] = &DiaryEntry{
+			ID:        uuid.New(),
+			UserID:    e.UserID,
+			UserDate:  userDate,
+			ProductID: e.ProductID,
+			RecipeID:  e.RecipeID,
+			Meal:      e.Meal,
+			Grams:     e.Grams,
+			Kcal:      e.Kcal,
+			Proteins:  e.Proteins,
+			Carbs:     e.Carbs,
+			Fats:      e.Fats,
+			Nutrients: e.Nutrients,
+			LoggedAt:  e.LoggedAt.AddDate(0, 0, days),
+		}
+	}
+	return copies
+}
+
+// ToCopyDto converts copied entries to the copy response
+func (es DiaryEntries) ToCopyDto(dryRun bool) *CopyDTO {
+	t := es.Totals()
+	return &CopyDTO{
+		DryRun:    dryRun,
+		Entries:   es.ToDto(),
+		Kcal:      nutrient.Kcal(t.Kcal),
+		Proteins:  nutrient.Macro(t.Proteins),
+		Carbs:     nutrient.Macro(t.Carbs),
+		Fats:      nutrient.Macro(t.Fats),
+		Nutrients: t.Nutrients.ToDto(),
+	}
+}
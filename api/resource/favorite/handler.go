@@ -0,0 +1,242 @@
+package favorite
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"fitapp-backend/api/resource/product"
+)
+
+// API holds the dependencies for the favorite and recent product handlers
+type API struct {
+	repository        *Repository
+	productRepository *product.Repository
+}
+
+// New creates a new API instance for favorite routes
+func New(db *gorm.DB) *API {
+	return &API{
+		repository:        NewRepository(db),
+		productRepository: product.NewRepository(db),
+	}
+}
+
+func handleErr(w http.ResponseWriter, status int, message string, err error) {
+	// Log the error internally
+	fmt.Printf("ERROR [%d]: %s - %v\n", status, message, err)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+func enableCors(w *http.ResponseWriter) {
+	(*w).Header().Set("Access-Control-Allow-Origin", "*")
+}
+
+// parseIDs parses the user and product IDs from the URL.
+// Returns false if an error response has been written.
+func parseIDs(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid user ID format (must be UUID)", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+	productID, err := uuid.Parse(chi.URLParam(r, "productId"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid product ID format (must be UUID)", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, productID, true
+}
+
+// List godoc
+//
+//	@summary		List favorite products
+//	@description	List the products the user marked as favorite, most recently added first
+//	@tags			favorites
+//	@accept			json
+//	@produce		json
+//	@param			id	path		string	true	"User ID (UUID)"
+//	@success		200	{array}		DTO
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/favorites [get]
+func (a *API) List(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid user ID format (must be UUID)", err)
+		return
+	}
+
+	favorites, err := a.repository.List(userID)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to retrieve favorites", err)
+		return
+	}
+
+	// Products deleted or made private since they were favorited are hidden
+	visible := make(Favorites, 0, len(favorites))
+	for _, f := range favorites {
+		if f.Product.ID != uuid.Nil && f.Product.VisibleTo(userID) {
+			visible = append(visible, f)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(visible.ToDto()); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode response", err)
+		return
+	}
+}
+
+// Create godoc
+//
+//	@summary		Add favorite product
+//	@description	Mark a product as favorite. Adding a product that is already a favorite is a no-op.
+//	@tags			favorites
+//	@accept			json
+//	@produce		json
+//	@param			id			path	string	true	"User ID (UUID)"
+//	@param			productId	path	string	true	"Product ID (UUID)"
+//	@success		201	{object}	DTO "Returns the created favorite"
+//	@success		200	{object}	DTO "Product was already a favorite"
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/favorites/{productId} [post]
+func (a *API) Create(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	userID, productID, ok := parseIDs(w, r)
+	if !ok {
+		return
+	}
+
+	p, err := a.productRepository.Read(productID)
+	if err == nil && !p.VisibleTo(userID) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			handleErr(w, http.StatusNotFound, "Product not found", err)
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to read product", err)
+		}
+		return
+	}
+
+	status := http.StatusOK
+	favorite, err := a.repository.Read(userID, productID)
+	if err == gorm.ErrRecordNotFound {
+		favorite, err = a.repository.Create(&Favorite{ID: uuid.New(), UserID: userID, ProductID: productID})
+		if err != nil {
+			handleErr(w, http.StatusInternalServerError, "Failed to create favorite", err)
+			return
+		}
+		status = http.StatusCreated
+	} else if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to check existing favorites", err)
+		return
+	}
+	favorite.Product = *p
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(favorite.ToDto()); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode response", err)
+		return
+	}
+}
+
+// Delete godoc
+//
+//	@summary		Remove favorite product
+//	@description	Unmark a product as favorite
+//	@tags			favorites
+//	@accept			json
+//	@produce		json
+//	@param			id			path	string	true	"User ID (UUID)"
+//	@param			productId	path	string	true	"Product ID (UUID)"
+//	@success		200	"OK"
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/favorites/{productId} [delete]
+func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	userID, productID, ok := parseIDs(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := a.repository.Delete(userID, productID)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to delete favorite", err)
+		return
+	}
+	if rows == 0 {
+		handleErr(w, http.StatusNotFound, "Favorite not found", nil)
+		return
+	}
+}
+
+// Recents godoc
+//
+//	@summary		List recently logged products
+//	@description	List the products the user logged in the diary during the last 90 days,
+//	@description	ranked by frequency and recency: each entry counts with a weight that halves every week.
+//	@tags			favorites
+//	@accept			json
+//	@produce		json
+//	@param			id		path		string	true	"User ID (UUID)"
+//	@param			limit	query		int		false	"Maximum number of products (default 20, max 50)"
+//	@success		200		{array}		RecentDTO
+//	@failure		400		{object}	map[string]string "Bad Request"
+//	@failure		500		{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/recents [get]
+func (a *API) Recents(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid user ID format (must be UUID)", err)
+		return
+	}
+
+	limit := DefaultRecentLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > MaxRecentLimit {
+			handleErr(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", MaxRecentLimit), err)
+			return
+		}
+	}
+
+	recents, err := a.repository.Recent(userID, time.Now(), limit)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to retrieve recent products", err)
+		return
+	}
+	products, err := a.repository.Products(recents.ProductIDs())
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to retrieve products", err)
+		return
+	}
+	for id, p := range products {
+		if !p.VisibleTo(userID) {
+			delete(products, id)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(recents.ToDto(products)); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode response", err)
+		return
+	}
+}
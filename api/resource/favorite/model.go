@@ -0,0 +1,63 @@
+package favorite
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"fitapp-backend/api/resource/product"
+)
+
+// Recent products are ranked by frecency: every logged entry adds a weight
+// that halves each RecentHalfLife, so both frequency and recency count.
+const (
+	RecentHalfLife     = 7 * 24 * time.Hour
+	RecentWindow       = 90 * 24 * time.Hour // Older entries are ignored
+	DefaultRecentLimit = 20
+	MaxRecentLimit     = 50
+)
+
+// Favorite represents a product a user marked as favorite in the 'favorite_products' table
+type Favorite struct {
+	ID        uuid.UUID `gorm:"type:uuid;primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	UserID    uuid.UUID       `gorm:"type:uuid;not null"`
+	ProductID uuid.UUID       `gorm:"type:uuid;not null"`
+	Product   product.Product `gorm:"foreignKey:ProductID"`
+}
+
+// TableName overrides the inferred 'favorites' table name
+func (Favorite) TableName() string {
+	return "favorite_products"
+}
+
+// Favorites is a slice of Favorite pointers
+type Favorites []*Favorite
+
+// Recent aggregates a user's diary entries of a single product
+type Recent struct {
+	ProductID    uuid.UUID
+	TimesLogged  int
+	LastLoggedAt time.Time
+	Score        float64
+}
+
+// Recents is a slice of Recent pointers, ranked best first
+type Recents []*Recent
+
+// DTO represents a favorite product
+type DTO struct {
+	Product *product.DTO `json:"product"`
+	AddedAt string       `json:"added_at"` // RFC3339
+}
+
+// RecentDTO represents a recently logged product
+type RecentDTO struct {
+	Product      *product.DTO `json:"product"`
+	TimesLogged  int          `json:"times_logged"`
+	LastLoggedAt string       `json:"last_logged_at"` // RFC3339
+}
@@ -0,0 +1,94 @@
+package favorite
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"fitapp-backend/api/resource/product"
+)
+
+// Repository handles database operations for favorite products and recents
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new favorite repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// List retrieves a user's favorites with their products, most recently added first
+func (r *Repository) List(userID uuid.UUID) (Favorites, error) {
+	favorites := make([]*Favorite, 0)
+	err := r.db.Preload("Product").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&favorites).Error
+	if err != nil {
+		return nil, err
+	}
+	return favorites, nil
+}
+
+// Create inserts a new favorite into the database
+func (r *Repository) Create(favorite *Favorite) (*Favorite, error) {
+	if err := r.db.Omit("Product").Create(favorite).Error; err != nil {
+		return nil, err
+	}
+	return favorite, nil
+}
+
+// Read retrieves a user's favorite of a product
+func (r *Repository) Read(userID uuid.UUID, productID uuid.UUID) (*Favorite, error) {
+	favorite := &Favorite{}
+	err := r.db.Where("user_id = ? AND product_id = ?", userID, productID).First(favorite).Error
+	if err != nil {
+		return nil, err
+	}
+	return favorite, nil
+}
+
+// Delete performs a soft delete on a user's favorite product
+func (r *Repository) Delete(userID uuid.UUID, productID uuid.UUID) (int64, error) {
+	result := r.db.Where("user_id = ? AND product_id = ?", userID, productID).Delete(&Favorite{})
+	return result.RowsAffected, result.Error
+}
+
+// Recent ranks the products a user logged in the diary within RecentWindow
+// before now by frecency, see RecentHalfLife
+func (r *Repository) Recent(userID uuid.UUID, now time.Time, limit int) (Recents, error) {
+	recents := make(Recents, 0)
+	err := r.db.Table("diary_entries").
+		Select("product_id, COUNT(*) AS times_logged, MAX(logged_at) AS last_logged_at, "+
+			"SUM(POWER(0.5, EXTRACT(EPOCH FROM (? - logged_at)) / ?)) AS score",
+			now, RecentHalfLife.Seconds()).
+		Where("user_id = ? AND product_id IS NOT NULL AND logged_at BETWEEN ? AND ? AND deleted_at IS NULL",
+			userID, now.Add(-RecentWindow), now).
+		Group("product_id").
+		Order("score DESC, last_logged_at DESC").
+		Limit(limit).
+		Scan(&recents).Error
+	if err != nil {
+		return nil, err
+	}
+	return recents, nil
+}
+
+// Products loads the non-deleted products with the given IDs keyed by ID
+func (r *Repository) Products(ids []uuid.UUID) (map[uuid.UUID]*product.Product, error) {
+	products := make(product.Products, 0, len(ids))
+	if len(ids) > 0 {
+		if err := r.db.Where("id IN ?", ids).Find(&products).Error; err != nil {
+			return nil, err
+		}
+	}
+	byID := make(map[uuid.UUID]*product.Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+	return byID, nil
+}
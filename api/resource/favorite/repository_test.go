@@ -0,0 +1,157 @@
+package favorite_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"fitapp-backend/api/resource/favorite"
+	mockDB "fitapp-backend/mock/db"
+	testUtil "fitapp-backend/util/test"
+)
+
+var favoriteColumns = []string{"id", "created_at", "updated_at", "deleted_at", "user_id", "product_id"}
+
+func TestRepository_List(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := favorite.NewRepository(db)
+
+	userID, productID := uuid.New(), uuid.New()
+	mockRows := sqlmock.NewRows(favoriteColumns).
+		AddRow(uuid.New(), time.Now(), time.Now(), gorm.DeletedAt{}, userID, productID)
+	productRows := sqlmock.NewRows([]string{"id", "product_name", "kcal", "proteins", "carbs", "fats", "visibility"}).
+		AddRow(productID, "Oats", "379", "13.2", "67.7", "6.5", "global")
+
+	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "favorite_products" WHERE user_id = $1 AND "favorite_products"."deleted_at" IS NULL ORDER BY created_at DESC`)
+	mock.ExpectQuery(expectedSQL).WithArgs(userID).WillReturnRows(mockRows)
+	productSQL := regexp.QuoteMeta(`SELECT * FROM "products" WHERE "products"."id" = $1 AND "products"."deleted_at" IS NULL`)
+	mock.ExpectQuery(productSQL).WithArgs(productID).WillReturnRows(productRows)
+
+	favorites, err := repo.List(userID)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 1, len(favorites))
+	dtos := favorites.ToDto()
+	testUtil.Equal(t, "Oats", dtos[0].Product.ProductName)
+	testUtil.Equal(t, 379.0, dtos[0].Product.Kcal)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Read(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := favorite.NewRepository(db)
+
+	userID, productID := uuid.New(), uuid.New()
+	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "favorite_products" WHERE (user_id = $1 AND product_id = $2) AND "favorite_products"."deleted_at" IS NULL ORDER BY "favorite_products"."id" LIMIT $3`)
+	mock.ExpectQuery(expectedSQL).WithArgs(userID, productID, 1).WillReturnRows(sqlmock.NewRows(favoriteColumns))
+
+	_, err = repo.Read(userID, productID)
+	testUtil.Equal(t, gorm.ErrRecordNotFound, err)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Create(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := favorite.NewRepository(db)
+
+	fav := &favorite.Favorite{ID: uuid.New(), UserID: uuid.New(), ProductID: uuid.New()}
+
+	mock.ExpectBegin()
+	expectedSQL := regexp.QuoteMeta(`INSERT INTO "favorite_products" ("id","created_at","updated_at","deleted_at","user_id","product_id") VALUES ($1,$2,$3,$4,$5,$6)`)
+	mock.ExpectExec(expectedSQL).
+		WithArgs(fav.ID, mockDB.AnyTime{}, mockDB.AnyTime{}, nil, fav.UserID, fav.ProductID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	created, err := repo.Create(fav)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, fav.ID, created.ID)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Delete(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := favorite.NewRepository(db)
+
+	userID, productID := uuid.New(), uuid.New()
+	mock.ExpectBegin()
+	expectedSQL := regexp.QuoteMeta(`UPDATE "favorite_products" SET "deleted_at"=$1 WHERE (user_id = $2 AND product_id = $3) AND "favorite_products"."deleted_at" IS NULL`)
+	mock.ExpectExec(expectedSQL).
+		WithArgs(mockDB.AnyTime{}, userID, productID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	rows, err := repo.Delete(userID, productID)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, int64(1), rows)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Recent(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := favorite.NewRepository(db)
+
+	userID, oats, milk := uuid.New(), uuid.New(), uuid.New()
+	now := time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+	mockRows := sqlmock.NewRows([]string{"product_id", "times_logged", "last_logged_at", "score"}).
+		AddRow(oats, 12, now.Add(-24*time.Hour), 8.4).
+		AddRow(milk, 2, now.Add(-time.Hour), 1.9)
+
+	expectedSQL := regexp.QuoteMeta(`SELECT product_id, COUNT(*) AS times_logged, MAX(logged_at) AS last_logged_at, ` +
+		`SUM(POWER(0.5, EXTRACT(EPOCH FROM ($1 - logged_at)) / $2)) AS score FROM "diary_entries" ` +
+		`WHERE user_id = $3 AND product_id IS NOT NULL AND logged_at BETWEEN $4 AND $5 AND deleted_at IS NULL ` +
+		`GROUP BY "product_id" ORDER BY score DESC, last_logged_at DESC LIMIT $6`)
+	mock.ExpectQuery(expectedSQL).
+		WithArgs(now, favorite.RecentHalfLife.Seconds(), userID, now.Add(-favorite.RecentWindow), now, 10).
+		WillReturnRows(mockRows)
+
+	recents, err := repo.Recent(userID, now, 10)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 2, len(recents))
+	testUtil.Equal(t, oats, recents[0].ProductID)
+	testUtil.Equal(t, 12, recents[0].TimesLogged)
+	testUtil.Equal(t, milk, recents.ProductIDs()[1])
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Products(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := favorite.NewRepository(db)
+
+	oats, deleted := uuid.New(), uuid.New()
+	mockRows := sqlmock.NewRows([]string{"id", "product_name", "kcal", "proteins", "carbs", "fats", "visibility"}).
+		AddRow(oats, "Oats", "379", "13.2", "67.7", "6.5", "global")
+
+	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "products" WHERE id IN ($1,$2) AND "products"."deleted_at" IS NULL`)
+	mock.ExpectQuery(expectedSQL).WithArgs(oats, deleted).WillReturnRows(mockRows)
+
+	products, err := repo.Products([]uuid.UUID{oats, deleted})
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 1, len(products))
+
+	// Products missing from the result, e.g. deleted ones, are dropped from the ranking
+	recents := favorite.Recents{
+		{ProductID: deleted, TimesLogged: 5, LastLoggedAt: time.Now()},
+		{ProductID: oats, TimesLogged: 3, LastLoggedAt: time.Now()},
+	}
+	dtos := recents.ToDto(products)
+	testUtil.Equal(t, 1, len(dtos))
+	testUtil.Equal(t, "Oats", dtos[0].Product.ProductName)
+	testUtil.Equal(t, 3, dtos[0].TimesLogged)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,52 @@
+package favorite
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"fitapp-backend/api/resource/product"
+)
+
+// ToDto converts a Favorite with its preloaded product to its DTO representation
+func (f *Favorite) ToDto() *DTO {
+	return &DTO{
+		Product: f.Product.ToDto(),
+		AddedAt: f.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// ToDto converts a slice of Favorites to a slice of DTOs
+func (fs Favorites) ToDto() []*DTO {
+	dtos := make([]*DTO, len(fs))
+	for i, f := range fs {
+		dtos[i] = f.ToDto()
+	}
+	return dtos
+}
+
+// ProductIDs returns the IDs of the ranked products
+func (rs Recents) ProductIDs() []uuid.UUID {
+	ids := make([]uuid.UUID, len(rs))
+	for i, rc := range rs {
+		ids[i] = rc.ProductID
+	}
+	return ids
+}
+
+// ToDto converts the ranking to DTOs in rank order, dropping products missing from products
+func (rs Recents) ToDto(products map[uuid.UUID]*product.Product) []*RecentDTO {
+	dtos := make([]*RecentDTO, 0, len(rs))
+	for _, rc := range rs {
+		p, ok := products[rc.ProductID]
+		if !ok {
+			continue
+		}
+		dtos = append(dtos, &RecentDTO{
+			Product:      p.ToDto(),
+			TimesLogged:  rc.TimesLogged,
+			LastLoggedAt: rc.LastLoggedAt.Format(time.RFC3339),
+		})
+	}
+	return dtos
+}
@@ -0,0 +1,301 @@
+package goal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"fitapp-backend/api/resource/user"
+)
+
+// API holds the dependencies for the goal handlers
+type API struct {
+	repository     *Repository
+	userRepository *user.Repository
+}
+
+// New creates a new API instance for goal routes
+func New(db *gorm.DB) *API {
+	return &API{
+		repository:     NewRepository(db),
+		userRepository: user.NewRepository(db),
+	}
+}
+
+func handleErr(w http.ResponseWriter, status int, message string, err error) {
+	// Log the error internally
+	fmt.Printf("ERROR [%d]: %s - %v\n", status, message, err)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+func enableCors(w *http.ResponseWriter) {
+	(*w).Header().Set("Access-Control-Allow-Origin", "*")
+}
+
+// readUser loads the user of the path. Returns nil if an error response has been written.
+func (a *API) readUser(w http.ResponseWriter, r *http.Request) *user.User {
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid user ID format (must be UUID)", err)
+		return nil
+	}
+	u, err := a.userRepository.Read(userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			handleErr(w, http.StatusNotFound, "User not found", err)
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to read user", err)
+		}
+		return nil
+	}
+	return u
+}
+
+// parseDate parses an optional date, defaulting to the user's today.
+// Returns false if an error response has been written.
+func parseDate(w http.ResponseWriter, u *user.User, field string, value string) (time.Time, bool) {
+	if value == "" {
+		return u.Today(), true
+	}
+	date, err := time.Parse(DateFormat, value)
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, fmt.Sprintf("Invalid %s format (must be %s)", field, DateFormat), err)
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// TargetOn resolves the target of the goal in effect on the user's date.
+// Returns nil without error if the user had no goal on that date.
+func (a *API) TargetOn(u *user.User, date time.Time) (*Target, error) {
+	g, err := a.repository.InEffect(u.ID, date)
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	weight, err := a.bodyWeight(u, date)
+	if err != nil {
+		return nil, err
+	}
+	return g.Target(weight), nil
+}
+
+// bodyWeight returns the weight per-kg goals are multiplied by on the date: the
+// latest weight entry on or before it, or the profile weight if there is none
+func (a *API) bodyWeight(u *user.User, date time.Time) (decimal.Decimal, error) {
+	weight, ok, err := a.repository.WeightOn(u.ID, date)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if !ok {
+		return decimal.NewFromInt(int64(u.Weight)), nil
+	}
+	return weight, nil
+}
+
+// List godoc
+//
+//	@summary		List goals
+//	@description	List the user's nutrition goal history, latest effective date first
+//	@tags			goals
+//	@accept			json
+//	@produce		json
+//	@param			id	path		string	true	"User ID (UUID)"
+//	@success		200	{array}		DTO
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/goals [get]
+func (a *API) List(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid user ID format (must be UUID)", err)
+		return
+	}
+
+	goals, err := a.repository.List(userID)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to retrieve goals", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(goals.ToDto()); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode response", err)
+		return
+	}
+}
+
+// Create godoc
+//
+//	@summary		Set goal
+//	@description	Set nutrition goals effective from a date (default: today in the user's timezone) until the next goal.
+//	@description	Earlier days keep their goals; a goal with the same effective date is replaced.
+//	@tags			goals
+//	@accept			json
+//	@produce		json
+//	@param			id		path	string	true	"User ID (UUID)"
+//	@param			body	body	Form	true	"Goal form"
+//	@success		201	{object}	DTO "Returns the stored goal"
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		422	{object}	map[string]string "Unprocessable Entity"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/goals [post]
+func (a *API) Create(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	form := &Form{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid request body JSON", err)
+		return
+	}
+	if form.Basis == "" {
+		form.Basis = BasisAbsolute
+	}
+	if err := form.Validate(); err != nil {
+		handleErr(w, http.StatusUnprocessableEntity, err.Error(), err)
+		return
+	}
+
+	u := a.readUser(w, r)
+	if u == nil {
+		return
+	}
+	effectiveFrom, ok := parseDate(w, u, "effective_from", form.EffectiveFrom)
+	if !ok {
+		return
+	}
+
+	g := &Goal{
+		ID:            uuid.New(), // Used only if there is no goal with this effective date yet
+		UserID:        u.ID,
+		EffectiveFrom: effectiveFrom,
+		Basis:         form.Basis,
+		Kcal:          form.Kcal,
+		Proteins:      form.Proteins,
+		Carbs:         form.Carbs,
+		Fats:          form.Fats,
+
+		TargetWeightKg: decimal.NullDecimal{Valid: form.TargetWeightKg != nil},
+	}
+	if form.TargetWeightKg != nil {
+		g.TargetWeightKg.Decimal = *form.TargetWeightKg
+	}
+
+	goal, err := a.repository.Upsert(g)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to set goal", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(goal.ToDto()); err != nil {
+		fmt.Printf("Error encoding created goal response: %v\n", err)
+	}
+}
+
+// Current godoc
+//
+//	@summary		Read goal in effect
+//	@description	Read the goal in effect on a date (default: today in the user's timezone) with its target
+//	@description	resolved to absolute amounts
+//	@tags			goals
+//	@accept			json
+//	@produce		json
+//	@param			id		path		string	true	"User ID (UUID)"
+//	@param			date	query		string	false	"Date (YYYY-MM-DD)"
+//	@success		200	{object}	InEffectDTO
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/goals/current [get]
+func (a *API) Current(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	u := a.readUser(w, r)
+	if u == nil {
+		return
+	}
+	date, ok := parseDate(w, u, "date", r.URL.Query().Get("date"))
+	if !ok {
+		return
+	}
+
+	goal, err := a.repository.InEffect(u.ID, date)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			handleErr(w, http.StatusNotFound, "No goal in effect on this date", err)
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to read goal", err)
+		}
+		return
+	}
+
+	weight, err := a.bodyWeight(u, date)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to read body weight", err)
+		return
+	}
+
+	dto := &InEffectDTO{
+		DTO:    goal.ToDto(),
+		Target: goal.Target(weight).ToDto(),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(dto); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode response", err)
+		return
+	}
+}
+
+// Delete godoc
+//
+//	@summary		Delete goal
+//	@description	Soft delete a goal; days it covered fall back to the previous goal
+//	@tags			goals
+//	@accept			json
+//	@produce		json
+//	@param			id		path	string	true	"User ID (UUID)"
+//	@param			goalId	path	string	true	"Goal ID (UUID)"
+//	@success		200	{object}	map[string]string "Successfully deleted"
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/goals/{goalId} [delete]
+func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid user ID format (must be UUID)", err)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "goalId"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid goal ID format (must be UUID)", err)
+		return
+	}
+
+	rowsAffected, err := a.repository.Delete(userID, id)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to delete goal", err)
+		return
+	}
+	if rowsAffected == 0 {
+		handleErr(w, http.StatusNotFound, "Goal not found or already deleted", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Goal deleted successfully"})
+}
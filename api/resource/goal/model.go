@@ -0,0 +1,87 @@
+package goal
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Bases of the goal values
+const (
+	BasisAbsolute = "absolute" // kcal and grams per day
+	BasisPerKg    = "per_kg"   // kcal and grams per day per kg of body weight
+)
+
+// Goal represents a user's daily nutrition targets in the 'goals' table.
+// A goal stays in effect from EffectiveFrom until the next goal of the user,
+// so changing goals keeps the targets of past days intact.
+type Goal struct {
+	ID        uuid.UUID `gorm:"type:uuid;primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	UserID        uuid.UUID       `gorm:"type:uuid;not null"`
+	EffectiveFrom time.Time       `gorm:"type:date;not null"`
+	Basis         string          `gorm:"not null"`
+	Kcal          decimal.Decimal `gorm:"type:numeric(10,2);not null"`
+	Proteins      decimal.Decimal `gorm:"type:numeric(10,2);not null"`
+	Carbs         decimal.Decimal `gorm:"type:numeric(10,2);not null"`
+	Fats          decimal.Decimal `gorm:"type:numeric(10,2);not null"`
+
+	TargetWeightKg decimal.NullDecimal `gorm:"type:numeric(5,2)"` // Optional body weight to reach
+}
+
+// Goals is a slice of Goal pointers
+type Goals []*Goal
+
+// Target holds the absolute daily amounts of a goal for a specific day
+type Target struct {
+	Kcal     decimal.Decimal
+	Proteins decimal.Decimal
+	Carbs    decimal.Decimal
+	Fats     decimal.Decimal
+}
+
+// DTO represents the data transfer object for a Goal
+type DTO struct {
+	ID            string  `json:"id"`
+	UserID        string  `json:"user_id"`
+	EffectiveFrom string  `json:"effective_from"` // Format "YYYY-MM-DD"
+	Basis         string  `json:"basis"`
+	Kcal          float64 `json:"kcal"`
+	Proteins      float64 `json:"proteins"`
+	Carbs         float64 `json:"carbs"`
+	Fats          float64 `json:"fats"`
+
+	TargetWeightKg *float64 `json:"target_weight_kg,omitempty"`
+}
+
+// TargetDTO represents a Target rounded for the API
+type TargetDTO struct {
+	Kcal     float64 `json:"kcal"`
+	Proteins float64 `json:"proteins"`
+	Carbs    float64 `json:"carbs"`
+	Fats     float64 `json:"fats"`
+}
+
+// InEffectDTO represents the goal in effect on a date with its resolved target
+type InEffectDTO struct {
+	*DTO
+	Target *TargetDTO `json:"target"`
+}
+
+// Form represents the data structure for setting a Goal.
+// UserID comes from the URL path.
+type Form struct {
+	EffectiveFrom string          `json:"effective_from"` // Optional, "YYYY-MM-DD"; defaults to the user's today
+	Basis         string          `json:"basis"`          // Optional, "absolute" (default) or "per_kg"
+	Kcal          decimal.Decimal `json:"kcal"`
+	Proteins      decimal.Decimal `json:"proteins"`
+	Carbs         decimal.Decimal `json:"carbs"`
+	Fats          decimal.Decimal `json:"fats"`
+
+	TargetWeightKg *decimal.Decimal `json:"target_weight_kg"` // Optional
+}
@@ -0,0 +1,82 @@
+package goal
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository handles database operations for goals
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new goal repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// List retrieves the goal history of a user, latest first
+func (r *Repository) List(userID uuid.UUID) (Goals, error) {
+	goals := make([]*Goal, 0)
+	if err := r.db.Where("user_id = ?", userID).Order("effective_from DESC").Find(&goals).Error; err != nil {
+		return nil, err
+	}
+	return goals, nil
+}
+
+// Upsert inserts the goal, or replaces the values of the user's goal with the
+// same effective date. Returns the stored goal.
+func (r *Repository) Upsert(goal *Goal) (*Goal, error) {
+	err := r.db.Clauses(
+		clause.OnConflict{
+			Columns:     []clause.Column{{Name: "user_id"}, {Name: "effective_from"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_at IS NULL"}}},
+			DoUpdates:   clause.AssignmentColumns([]string{"basis", "kcal", "proteins", "carbs", "fats", "target_weight_kg", "updated_at"}),
+		},
+		clause.Returning{},
+	).Create(goal).Error
+	if err != nil {
+		return nil, err
+	}
+	return goal, nil
+}
+
+// InEffect retrieves the goal in effect on the date, the latest one starting on or before it
+func (r *Repository) InEffect(userID uuid.UUID, date time.Time) (*Goal, error) {
+	goal := &Goal{}
+	dateStr := date.Format(DateFormat)
+	err := r.db.Where("user_id = ? AND effective_from <= ?", userID, dateStr).
+		Order("effective_from DESC").
+		First(goal).Error
+	if err != nil {
+		return nil, err // Can be gorm.ErrRecordNotFound
+	}
+	return goal, nil
+}
+
+// WeightOn retrieves the user's weight from the latest weight entry on or before
+// the date. Returns false if the user logged no weight until then.
+func (r *Repository) WeightOn(userID uuid.UUID, date time.Time) (decimal.Decimal, bool, error) {
+	weights := make([]decimal.Decimal, 0, 1)
+	err := r.db.Table("weight_entries").
+		Where("user_id = ? AND entry_date <= ? AND deleted_at IS NULL", userID, date.Format(DateFormat)).
+		Order("entry_date DESC").
+		Limit(1).
+		Pluck("weight_kg", &weights).Error
+	if err != nil || len(weights) == 0 {
+		return decimal.Zero, false, err
+	}
+	return weights[0], true, nil
+}
+
+// Delete performs a soft delete on a user's goal; the previous goal then applies again
+func (r *Repository) Delete(userID uuid.UUID, id uuid.UUID) (int64, error) {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&Goal{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,167 @@
+package goal_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"fitapp-backend/api/resource/goal"
+	mockDB "fitapp-backend/mock/db"
+	testUtil "fitapp-backend/util/test"
+)
+
+var goalColumns = []string{"id", "created_at", "updated_at", "deleted_at", "user_id", "effective_from", "basis", "kcal", "proteins", "carbs", "fats", "target_weight_kg"}
+
+func TestRepository_List(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := goal.NewRepository(db)
+
+	userID := uuid.New()
+	mockRows := sqlmock.NewRows(goalColumns).
+		AddRow(uuid.New(), time.Now(), time.Now(), nil, userID, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), goal.BasisAbsolute, "2200", "150", "220", "70", "75.5").
+		AddRow(uuid.New(), time.Now(), time.Now(), nil, userID, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), goal.BasisPerKg, "30", "1.8", "3", "1", nil)
+
+	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "goals" WHERE user_id = $1 AND "goals"."deleted_at" IS NULL ORDER BY effective_from DESC`)
+	mock.ExpectQuery(expectedSQL).WithArgs(userID).WillReturnRows(mockRows)
+
+	goals, err := repo.List(userID)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 2, len(goals))
+	dtos := goals.ToDto()
+	testUtil.Equal(t, "2024-05-01", dtos[0].EffectiveFrom)
+	testUtil.Equal(t, 75.5, *dtos[0].TargetWeightKg)
+	testUtil.Equal(t, 1.8, dtos[1].Proteins)
+	testUtil.Equal(t, true, dtos[1].TargetWeightKg == nil)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Upsert(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := goal.NewRepository(db)
+
+	existingID := uuid.New()
+	effectiveFrom := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	g := &goal.Goal{
+		ID:            uuid.New(),
+		UserID:        uuid.New(),
+		EffectiveFrom: effectiveFrom,
+		Basis:         goal.BasisAbsolute,
+		Kcal:          decimal.NewFromInt(2000),
+		Proteins:      decimal.NewFromInt(140),
+		Carbs:         decimal.NewFromInt(200),
+		Fats:          decimal.NewFromInt(65),
+	}
+	mockRows := sqlmock.NewRows(goalColumns).
+		AddRow(existingID, time.Now(), time.Now(), nil, g.UserID, effectiveFrom, goal.BasisAbsolute, "2000", "140", "200", "65", nil)
+
+	mock.ExpectBegin()
+	expectedSQL := regexp.QuoteMeta(`INSERT INTO "goals" ("id","created_at","updated_at","deleted_at","user_id","effective_from","basis","kcal","proteins","carbs","fats","target_weight_kg") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12) ON CONFLICT ("user_id","effective_from") WHERE deleted_at IS NULL DO UPDATE SET "basis"="excluded"."basis","kcal"="excluded"."kcal","proteins"="excluded"."proteins","carbs"="excluded"."carbs","fats"="excluded"."fats","target_weight_kg"="excluded"."target_weight_kg","updated_at"="excluded"."updated_at" RETURNING *`)
+	mock.ExpectQuery(expectedSQL).
+		WithArgs(g.ID, mockDB.AnyTime{}, mockDB.AnyTime{}, nil, g.UserID, effectiveFrom, goal.BasisAbsolute, "2000", "140", "200", "65", nil).
+		WillReturnRows(mockRows)
+	mock.ExpectCommit()
+
+	stored, err := repo.Upsert(g)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, existingID, stored.ID) // The goal with the same effective date is replaced
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_InEffect(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := goal.NewRepository(db)
+
+	userID := uuid.New()
+	date := time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)
+	mockRows := sqlmock.NewRows(goalColumns).
+		AddRow(uuid.New(), time.Now(), time.Now(), nil, userID, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), goal.BasisPerKg, "30", "2", "3.5", "0.9", nil)
+
+	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "goals" WHERE (user_id = $1 AND effective_from <= $2) AND "goals"."deleted_at" IS NULL ORDER BY effective_from DESC,"goals"."id" LIMIT $3`)
+	mock.ExpectQuery(expectedSQL).WithArgs(userID, "2024-06-15", 1).WillReturnRows(mockRows)
+
+	g, err := repo.InEffect(userID, date)
+	testUtil.NoError(t, err)
+
+	// Per-kg goals are multiplied by the body weight
+	target := g.Target(decimal.NewFromInt(80))
+	testUtil.Equal(t, "2400", target.Kcal.String())
+	testUtil.Equal(t, "160", target.Proteins.String())
+	testUtil.Equal(t, "72", target.Fats.String())
+
+	remaining := target.Remaining(decimal.NewFromInt(2500), decimal.NewFromInt(100), decimal.NewFromInt(200), decimal.NewFromInt(50)).ToDto()
+	testUtil.Equal(t, -100.0, remaining.Kcal)
+	testUtil.Equal(t, 60.0, remaining.Proteins)
+	testUtil.Equal(t, 80.0, remaining.Carbs)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_InEffect_NotFound(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := goal.NewRepository(db)
+
+	userID := uuid.New()
+	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "goals" WHERE (user_id = $1 AND effective_from <= $2) AND "goals"."deleted_at" IS NULL ORDER BY effective_from DESC,"goals"."id" LIMIT $3`)
+	mock.ExpectQuery(expectedSQL).WithArgs(userID, "2023-12-31", 1).WillReturnRows(sqlmock.NewRows(goalColumns))
+
+	_, err = repo.InEffect(userID, time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC))
+	testUtil.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_WeightOn(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := goal.NewRepository(db)
+
+	userID := uuid.New()
+	expectedSQL := regexp.QuoteMeta(`SELECT "weight_kg" FROM "weight_entries" WHERE user_id = $1 AND entry_date <= $2 AND deleted_at IS NULL ORDER BY entry_date DESC LIMIT $3`)
+	mock.ExpectQuery(expectedSQL).WithArgs(userID, "2024-06-15", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"weight_kg"}).AddRow("82.4"))
+
+	// Per-kg targets use the weight logged on or before the date, not today's
+	weight, ok, err := repo.WeightOn(userID, time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC))
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, true, ok)
+	testUtil.Equal(t, "82.4", weight.String())
+
+	// Without earlier entries the caller falls back to the profile weight
+	mock.ExpectQuery(expectedSQL).WithArgs(userID, "2023-12-31", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"weight_kg"}))
+
+	_, ok, err = repo.WeightOn(userID, time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC))
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, false, ok)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Delete(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := goal.NewRepository(db)
+
+	id, userID := uuid.New(), uuid.New()
+	mock.ExpectBegin()
+	expectedSQL := regexp.QuoteMeta(`UPDATE "goals" SET "deleted_at"=$1 WHERE (id = $2 AND user_id = $3) AND "goals"."deleted_at" IS NULL`)
+	mock.ExpectExec(expectedSQL).WithArgs(mockDB.AnyTime{}, id, userID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	rowsAffected, err := repo.Delete(userID, id)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, int64(1), rowsAffected)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,98 @@
+package goal
+
+import (
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"fitapp-backend/api/resource/common/nutrient"
+)
+
+// DateFormat is the format of effective dates, matching user dates
+const DateFormat = time.DateOnly
+
+// ToDto converts a Goal model to its DTO representation
+func (g *Goal) ToDto() *DTO {
+	return &DTO{
+		ID:            g.ID.String(),
+		UserID:        g.UserID.String(),
+		EffectiveFrom: g.EffectiveFrom.Format(DateFormat),
+		Basis:         g.Basis,
+		Kcal:          g.Kcal.InexactFloat64(),
+		Proteins:      g.Proteins.InexactFloat64(),
+		Carbs:         g.Carbs.InexactFloat64(),
+		Fats:          g.Fats.InexactFloat64(),
+
+		TargetWeightKg: nullFloat(g.TargetWeightKg),
+	}
+}
+
+// nullFloat converts an optional decimal, returning nil if it is not set
+func nullFloat(d decimal.NullDecimal) *float64 {
+	if !d.Valid {
+		return nil
+	}
+	f := d.Decimal.InexactFloat64()
+	return &f
+}
+
+// ToDto converts a slice of Goal models to a slice of DTOs
+func (gs Goals) ToDto() []*DTO {
+	dtos := make([]*DTO, len(gs))
+	for i, g := range gs {
+		dtos[i] = g.ToDto()
+	}
+	return dtos
+}
+
+// Validate checks the basis and that the kcal target is positive and no macro is negative
+func (f *Form) Validate() error {
+	if f.Basis != BasisAbsolute && f.Basis != BasisPerKg {
+		return errors.New("basis must be absolute or per_kg")
+	}
+	if !f.Kcal.IsPositive() {
+		return errors.New("kcal must be greater than zero")
+	}
+	if f.Proteins.IsNegative() || f.Carbs.IsNegative() || f.Fats.IsNegative() {
+		return errors.New("macronutrients cannot be negative")
+	}
+	if f.TargetWeightKg != nil && !f.TargetWeightKg.IsPositive() {
+		return errors.New("target_weight_kg must be greater than zero")
+	}
+	return nil
+}
+
+// Target resolves the goal to absolute daily amounts. weightKg is the user's
+// body weight on that day, used by per-kg goals.
+func (g *Goal) Target(weightKg decimal.Decimal) *Target {
+	t := &Target{Kcal: g.Kcal, Proteins: g.Proteins, Carbs: g.Carbs, Fats: g.Fats}
+	if g.Basis == BasisPerKg {
+		t.Kcal = nutrient.Round(t.Kcal.Mul(weightKg))
+		t.Proteins = nutrient.Round(t.Proteins.Mul(weightKg))
+		t.Carbs = nutrient.Round(t.Carbs.Mul(weightKg))
+		t.Fats = nutrient.Round(t.Fats.Mul(weightKg))
+	}
+	return t
+}
+
+// Remaining returns the amounts left after consuming the given totals.
+// Negative values mean the target was exceeded.
+func (t *Target) Remaining(kcal, proteins, carbs, fats decimal.Decimal) *Target {
+	return &Target{
+		Kcal:     t.Kcal.Sub(kcal),
+		Proteins: t.Proteins.Sub(proteins),
+		Carbs:    t.Carbs.Sub(carbs),
+		Fats:     t.Fats.Sub(fats),
+	}
+}
+
+// ToDto converts a Target to its DTO representation
+func (t *Target) ToDto() *TargetDTO {
+	return &TargetDTO{
+		Kcal:     nutrient.Kcal(t.Kcal),
+		Proteins: nutrient.Macro(t.Proteins),
+		Carbs:    nutrient.Macro(t.Carbs),
+		Fats:     nutrient.Macro(t.Fats),
+	}
+}
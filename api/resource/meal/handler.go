@@ -0,0 +1,175 @@
+package meal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// API holds the dependencies for the meal handlers
+type API struct {
+	repository *Repository
+}
+
+// New creates a new API instance for meal routes
+func New(db *gorm.DB) *API {
+	return &API{
+		repository: NewRepository(db),
+	}
+}
+
+func handleErr(w http.ResponseWriter, status int, message string, err error) {
+	// Log the error internally
+	fmt.Printf("ERROR [%d]: %s - %v\n", status, message, err)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+func enableCors(w *http.ResponseWriter) {
+	(*w).Header().Set("Access-Control-Allow-Origin", "*")
+}
+
+// List godoc
+//
+//	@summary		List meals
+//	@description	List the standard meal slots followed by the user's custom meals
+//	@tags			meals
+//	@accept			json
+//	@produce		json
+//	@param			id	path		string	true	"User ID (UUID)"
+//	@success		200	{array}		DTO
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/meals [get]
+func (a *API) List(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid user ID format (must be UUID)", err)
+		return
+	}
+
+	meals, err := a.repository.List(userID)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to retrieve meals", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(meals.ToDto()); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode response", err)
+		return
+	}
+}
+
+// Create godoc
+//
+//	@summary		Create custom meal
+//	@description	Add a user-defined meal name that diary entries can be logged under
+//	@tags			meals
+//	@accept			json
+//	@produce		json
+//	@param			id		path	string	true	"User ID (UUID)"
+//	@param			body	body	Form	true	"Custom meal form"
+//	@success		201	{object}	DTO "Returns the created meal"
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		422	{object}	map[string]string "Unprocessable Entity"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/meals [post]
+func (a *API) Create(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid user ID format (must be UUID)", err)
+		return
+	}
+
+	form := &Form{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid request body JSON", err)
+		return
+	}
+
+	name := NormalizeName(form.MealName)
+	if name == "" || len(name) > 50 {
+		handleErr(w, http.StatusUnprocessableEntity, "meal_name must be between 1 and 50 characters", nil)
+		return
+	}
+
+	exists, err := a.repository.Exists(userID, name)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to check existing meals", err)
+		return
+	}
+	if exists || IsStandard(name) {
+		handleErr(w, http.StatusUnprocessableEntity, "Meal with this name already exists", nil)
+		return
+	}
+
+	newMeal := &CustomMeal{
+		ID:       uuid.New(),
+		UserID:   userID,
+		MealName: name,
+	}
+
+	createdMeal, err := a.repository.Create(newMeal)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to create meal", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(createdMeal.ToDto()); err != nil {
+		fmt.Printf("Error encoding created meal response: %v\n", err)
+	}
+}
+
+// Delete godoc
+//
+//	@summary		Delete custom meal
+//	@description	Soft delete a user-defined meal; already logged entries keep their meal name
+//	@tags			meals
+//	@accept			json
+//	@produce		json
+//	@param			id		path	string	true	"User ID (UUID)"
+//	@param			mealId	path	string	true	"Custom meal ID (UUID)"
+//	@success		200	{object}	map[string]string "Successfully deleted"
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/meals/{mealId} [delete]
+func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid user ID format (must be UUID)", err)
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "mealId"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid meal ID format (must be UUID)", err)
+		return
+	}
+
+	rowsAffected, err := a.repository.Delete(userID, id)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to delete meal", err)
+		return
+	}
+
+	if rowsAffected == 0 {
+		handleErr(w, http.StatusNotFound, "Meal not found or already deleted", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Meal deleted successfully"})
+}
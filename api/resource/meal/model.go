@@ -0,0 +1,45 @@
+package meal
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Standard meal slots every user has
+const (
+	Breakfast = "breakfast"
+	Lunch     = "lunch"
+	Dinner    = "dinner"
+	Snacks    = "snacks"
+)
+
+// Standard lists the built-in meal slots in the order they are presented
+var Standard = []string{Breakfast, Lunch, Dinner, Snacks}
+
+// CustomMeal represents a user-defined meal name in the 'custom_meals' table
+type CustomMeal struct {
+	ID        uuid.UUID `gorm:"type:uuid;primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	UserID   uuid.UUID `gorm:"type:uuid;not null"`
+	MealName string    `gorm:"not null"`
+}
+
+// CustomMeals is a slice of CustomMeal pointers
+type CustomMeals []*CustomMeal
+
+// DTO represents a meal slot available to the user
+type DTO struct {
+	ID       string `json:"id,omitempty"` // Empty for standard meals
+	MealName string `json:"meal_name"`
+	Custom   bool   `json:"custom"`
+}
+
+// Form represents the data structure for creating a CustomMeal
+type Form struct {
+	MealName string `json:"meal_name"`
+}
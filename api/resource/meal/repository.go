@@ -0,0 +1,50 @@
+package meal
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Repository handles database operations for custom_meals
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new meal repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// List retrieves all non-deleted custom meals of a user
+func (r *Repository) List(userID uuid.UUID) (CustomMeals, error) {
+	meals := make([]*CustomMeal, 0)
+	if err := r.db.Where("user_id = ?", userID).Order("meal_name").Find(&meals).Error; err != nil {
+		return nil, err
+	}
+	return meals, nil
+}
+
+// Create inserts a new custom meal into the database
+func (r *Repository) Create(meal *CustomMeal) (*CustomMeal, error) {
+	if err := r.db.Create(meal).Error; err != nil {
+		return nil, err
+	}
+	return meal, nil
+}
+
+// Exists reports whether the user has a custom meal with the given name
+func (r *Repository) Exists(userID uuid.UUID, name string) (bool, error) {
+	var count int64
+	if err := r.db.Model(&CustomMeal{}).Where("user_id = ? AND meal_name = ?", userID, name).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Delete performs a soft delete on a custom meal, scoped to its owner
+func (r *Repository) Delete(userID uuid.UUID, id uuid.UUID) (int64, error) {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&CustomMeal{})
+	return result.RowsAffected, result.Error
+}
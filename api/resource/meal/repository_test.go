@@ -0,0 +1,57 @@
+package meal_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"fitapp-backend/api/resource/meal"
+	mockDB "fitapp-backend/mock/db"
+	testUtil "fitapp-backend/util/test"
+)
+
+var mealColumns = []string{"id", "created_at", "updated_at", "deleted_at", "user_id", "meal_name"}
+
+func TestRepository_List(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := meal.NewRepository(db)
+
+	userID := uuid.New()
+	mockRows := sqlmock.NewRows(mealColumns).
+		AddRow(uuid.New(), time.Now(), time.Now(), gorm.DeletedAt{}, userID, "pre-workout")
+
+	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "custom_meals" WHERE user_id = $1 AND "custom_meals"."deleted_at" IS NULL ORDER BY meal_name`)
+	mock.ExpectQuery(expectedSQL).WithArgs(userID).WillReturnRows(mockRows)
+
+	meals, err := repo.List(userID)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 1, len(meals))
+	// Standard slots are listed before custom meals
+	dtos := meals.ToDto()
+	testUtil.Equal(t, len(meal.Standard)+1, len(dtos))
+	testUtil.Equal(t, meal.Breakfast, dtos[0].MealName)
+	testUtil.Equal(t, "pre-workout", dtos[len(dtos)-1].MealName)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Exists(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := meal.NewRepository(db)
+
+	userID := uuid.New()
+	expectedSQL := regexp.QuoteMeta(`SELECT count(*) FROM "custom_meals" WHERE (user_id = $1 AND meal_name = $2) AND "custom_meals"."deleted_at" IS NULL`)
+	mock.ExpectQuery(expectedSQL).WithArgs(userID, "supper").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	exists, err := repo.Exists(userID, "supper")
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, true, exists)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,49 @@
+package meal
+
+import "strings"
+
+// IsStandard reports whether name is one of the built-in meal slots
+func IsStandard(name string) bool {
+	for _, m := range Standard {
+		if m == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Rank returns the display position of a meal; custom meals go after the standard ones
+func Rank(name string) int {
+	for i, m := range Standard {
+		if m == name {
+			return i
+		}
+	}
+	return len(Standard)
+}
+
+// NormalizeName trims and lowercases a meal name so lookups are consistent
+func NormalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// ToDto converts a CustomMeal model to its DTO representation
+func (m *CustomMeal) ToDto() *DTO {
+	return &DTO{
+		ID:       m.ID.String(),
+		MealName: m.MealName,
+		Custom:   true,
+	}
+}
+
+// ToDto lists the standard meal slots followed by the user's custom meals
+func (ms CustomMeals) ToDto() []*DTO {
+	dtos := make([]*DTO, 0, len(Standard)+len(ms))
+	for _, name := range Standard {
+		dtos = append(dtos, &DTO{MealName: name})
+	}
+	for _, m := range ms {
+		dtos = append(dtos, m.ToDto())
+	}
+	return dtos
+}
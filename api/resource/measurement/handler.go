@@ -0,0 +1,350 @@
+package measurement
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"fitapp-backend/api/resource/user"
+)
+
+// API holds the dependencies for the measurement handlers
+type API struct {
+	repository     *Repository
+	userRepository *user.Repository
+}
+
+// New creates a new API instance for measurement routes
+func New(db *gorm.DB) *API {
+	return &API{
+		repository:     NewRepository(db),
+		userRepository: user.NewRepository(db),
+	}
+}
+
+func handleErr(w http.ResponseWriter, status int, message string, err error) {
+	// Log the error internally
+	fmt.Printf("ERROR [%d]: %s - %v\n", status, message, err)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+func enableCors(w *http.ResponseWriter) {
+	(*w).Header().Set("Access-Control-Allow-Origin", "*")
+}
+
+// readUser loads the user of the path, whose height and sex the body fat
+// estimate needs. Returns nil if an error response has been written.
+func (a *API) readUser(w http.ResponseWriter, r *http.Request) *user.User {
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid user ID format (must be UUID)", err)
+		return nil
+	}
+	u, err := a.userRepository.Read(userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			handleErr(w, http.StatusNotFound, "User not found", err)
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to read user", err)
+		}
+		return nil
+	}
+	return u
+}
+
+// parseMeasurementID reads the measurement ID path parameter
+func parseMeasurementID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "measurementId"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid measurement ID format (must be UUID)", err)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// parseDate parses an optional date query or form value.
+// Returns false if an error response has been written.
+func parseDate(w http.ResponseWriter, field string, value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, true
+	}
+	date, err := time.Parse(DateFormat, value)
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, fmt.Sprintf("Invalid %s format (must be %s)", field, DateFormat), err)
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// fillFromForm validates the form and applies it to the measurement, defaulting
+// the date to the user's today. Returns false if an error response has been written.
+func (a *API) fillFromForm(w http.ResponseWriter, u *user.User, form *Form, m *Measurement) bool {
+	if err := form.Validate(); err != nil {
+		handleErr(w, http.StatusUnprocessableEntity, err.Error(), err)
+		return false
+	}
+	date, ok := parseDate(w, "date", form.Date)
+	if !ok {
+		return false
+	}
+	if date.IsZero() {
+		date = u.Today()
+	}
+
+	exists, err := a.repository.ExistsOnDate(u.ID, date, m.ID)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to check existing measurements", err)
+		return false
+	}
+	if exists {
+		handleErr(w, http.StatusUnprocessableEntity, "A measurement for this date already exists", nil)
+		return false
+	}
+
+	m.MeasuredOn = date
+	form.apply(m)
+	return true
+}
+
+// List godoc
+//
+//	@summary		List body measurements
+//	@description	List the user's body measurements ordered by date, optionally within a date range,
+//	@description	each with the body fat percentage estimated by the US Navy method when possible
+//	@tags			measurements
+//	@accept			json
+//	@produce		json
+//	@param			id		path		string	true	"User ID (UUID)"
+//	@param			from	query		string	false	"First date (YYYY-MM-DD)"
+//	@param			to		query		string	false	"Last date (YYYY-MM-DD)"
+//	@success		200	{array}		DTO
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/measurements [get]
+func (a *API) List(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	u := a.readUser(w, r)
+	if u == nil {
+		return
+	}
+	from, ok := parseDate(w, "from", r.URL.Query().Get("from"))
+	if !ok {
+		return
+	}
+	to, ok := parseDate(w, "to", r.URL.Query().Get("to"))
+	if !ok {
+		return
+	}
+
+	measurements, err := a.repository.List(u.ID, from, to)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to retrieve measurements", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(measurements.ToDto(u)); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode response", err)
+		return
+	}
+}
+
+// Create godoc
+//
+//	@summary		Create body measurement
+//	@description	Record body circumferences for a date (default: today in the user's timezone).
+//	@description	Waist and neck (plus hips for women) allow a body fat estimate.
+//	@tags			measurements
+//	@accept			json
+//	@produce		json
+//	@param			id		path	string	true	"User ID (UUID)"
+//	@param			body	body	Form	true	"Measurement form"
+//	@success		201	{object}	DTO "Returns the created measurement"
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		422	{object}	map[string]string "Unprocessable Entity"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/measurements [post]
+func (a *API) Create(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	u := a.readUser(w, r)
+	if u == nil {
+		return
+	}
+
+	form := &Form{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid request body JSON", err)
+		return
+	}
+
+	newMeasurement := &Measurement{
+		ID:     uuid.New(),
+		UserID: u.ID,
+	}
+	if !a.fillFromForm(w, u, form, newMeasurement) {
+		return
+	}
+
+	created, err := a.repository.Create(newMeasurement)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to create measurement", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(created.ToDto(u)); err != nil {
+		fmt.Printf("Error encoding created measurement response: %v\n", err)
+	}
+}
+
+// Read godoc
+//
+//	@summary		Read body measurement
+//	@description	Read a single body measurement of the user with its body fat estimate
+//	@tags			measurements
+//	@accept			json
+//	@produce		json
+//	@param			id				path		string	true	"User ID (UUID)"
+//	@param			measurementId	path		string	true	"Measurement ID (UUID)"
+//	@success		200	{object}	DTO
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/measurements/{measurementId} [get]
+func (a *API) Read(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	u := a.readUser(w, r)
+	if u == nil {
+		return
+	}
+	id, ok := parseMeasurementID(w, r)
+	if !ok {
+		return
+	}
+
+	measurement, err := a.repository.Read(u.ID, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			handleErr(w, http.StatusNotFound, "Measurement not found", err)
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to read measurement", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(measurement.ToDto(u)); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode response", err)
+		return
+	}
+}
+
+// Update godoc
+//
+//	@summary		Update body measurement
+//	@description	Replace the date and circumferences of a measurement; omitted circumferences are cleared
+//	@tags			measurements
+//	@accept			json
+//	@produce		json
+//	@param			id				path		string	true	"User ID (UUID)"
+//	@param			measurementId	path		string	true	"Measurement ID (UUID)"
+//	@param			body			body		Form	true	"Measurement form"
+//	@success		200	{object}	DTO "Returns the updated measurement"
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		422	{object}	map[string]string "Unprocessable Entity"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/measurements/{measurementId} [put]
+func (a *API) Update(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	u := a.readUser(w, r)
+	if u == nil {
+		return
+	}
+	id, ok := parseMeasurementID(w, r)
+	if !ok {
+		return
+	}
+
+	form := &Form{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid request body JSON", err)
+		return
+	}
+
+	measurement, err := a.repository.Read(u.ID, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			handleErr(w, http.StatusNotFound, "Measurement not found", err)
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to read measurement", err)
+		}
+		return
+	}
+	if form.Date == "" {
+		form.Date = measurement.MeasuredOn.Format(DateFormat) // Keep the date unless it is changed
+	}
+	if !a.fillFromForm(w, u, form, measurement) {
+		return
+	}
+
+	if _, err := a.repository.Update(measurement); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to update measurement", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(measurement.ToDto(u))
+}
+
+// Delete godoc
+//
+//	@summary		Delete body measurement
+//	@description	Soft delete a body measurement of the user
+//	@tags			measurements
+//	@accept			json
+//	@produce		json
+//	@param			id				path	string	true	"User ID (UUID)"
+//	@param			measurementId	path	string	true	"Measurement ID (UUID)"
+//	@success		200	{object}	map[string]string "Successfully deleted"
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/measurements/{measurementId} [delete]
+func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid user ID format (must be UUID)", err)
+		return
+	}
+	id, ok := parseMeasurementID(w, r)
+	if !ok {
+		return
+	}
+
+	rowsAffected, err := a.repository.Delete(userID, id)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to delete measurement", err)
+		return
+	}
+	if rowsAffected == 0 {
+		handleErr(w, http.StatusNotFound, "Measurement not found or already deleted", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Measurement deleted successfully"})
+}
@@ -0,0 +1,69 @@
+package measurement
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Limits of accepted circumferences in centimeters
+var (
+	MinCircumferenceCm = decimal.NewFromInt(10)
+	MaxCircumferenceCm = decimal.NewFromInt(300)
+)
+
+// Measurement represents body circumferences taken on a date in the
+// 'body_measurements' table. Every circumference is optional; a user has at
+// most one measurement per date.
+type Measurement struct {
+	ID        uuid.UUID `gorm:"type:uuid;primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	UserID     uuid.UUID           `gorm:"type:uuid;not null"`
+	MeasuredOn time.Time           `gorm:"type:date;not null"`
+	WaistCm    decimal.NullDecimal `gorm:"type:numeric(5,1)"` // At the navel
+	NeckCm     decimal.NullDecimal `gorm:"type:numeric(5,1)"` // Below the larynx
+	HipsCm     decimal.NullDecimal `gorm:"type:numeric(5,1)"` // At the widest point
+	ChestCm    decimal.NullDecimal `gorm:"type:numeric(5,1)"`
+	ArmCm      decimal.NullDecimal `gorm:"type:numeric(5,1)"` // Relaxed upper arm
+	ThighCm    decimal.NullDecimal `gorm:"type:numeric(5,1)"`
+}
+
+// TableName overrides the inferred 'measurements' table name
+func (Measurement) TableName() string {
+	return "body_measurements"
+}
+
+// Measurements is a slice of Measurement pointers
+type Measurements []*Measurement
+
+// DTO represents the data transfer object for a Measurement. BodyFatPercent is
+// estimated with the US Navy method when the required circumferences are present.
+type DTO struct {
+	ID             string   `json:"id"`
+	UserID         string   `json:"user_id"`
+	Date           string   `json:"date"` // Format "YYYY-MM-DD"
+	WaistCm        *float64 `json:"waist_cm,omitempty"`
+	NeckCm         *float64 `json:"neck_cm,omitempty"`
+	HipsCm         *float64 `json:"hips_cm,omitempty"`
+	ChestCm        *float64 `json:"chest_cm,omitempty"`
+	ArmCm          *float64 `json:"arm_cm,omitempty"`
+	ThighCm        *float64 `json:"thigh_cm,omitempty"`
+	BodyFatPercent *float64 `json:"body_fat_percent,omitempty"`
+}
+
+// Form represents the data structure for creating/updating a Measurement.
+// UserID comes from the URL path; omitted circumferences are not stored.
+type Form struct {
+	Date    string           `json:"date"` // Optional, "YYYY-MM-DD"; defaults to the user's today
+	WaistCm *decimal.Decimal `json:"waist_cm"`
+	NeckCm  *decimal.Decimal `json:"neck_cm"`
+	HipsCm  *decimal.Decimal `json:"hips_cm"`
+	ChestCm *decimal.Decimal `json:"chest_cm"`
+	ArmCm   *decimal.Decimal `json:"arm_cm"`
+	ThighCm *decimal.Decimal `json:"thigh_cm"`
+}
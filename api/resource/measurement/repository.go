@@ -0,0 +1,81 @@
+package measurement
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Repository handles database operations for body_measurements
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new measurement repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// List retrieves a user's measurements between from and to (inclusive, either
+// may be zero for an open range) ordered by date
+func (r *Repository) List(userID uuid.UUID, from, to time.Time) (Measurements, error) {
+	measurements := make([]*Measurement, 0)
+	query := r.db.Where("user_id = ?", userID)
+	if !from.IsZero() {
+		query = query.Where("measured_on >= ?", from.Format(DateFormat))
+	}
+	if !to.IsZero() {
+		query = query.Where("measured_on <= ?", to.Format(DateFormat))
+	}
+	if err := query.Order("measured_on").Find(&measurements).Error; err != nil {
+		return nil, err
+	}
+	return measurements, nil
+}
+
+// Create inserts a new measurement into the database
+func (r *Repository) Create(measurement *Measurement) (*Measurement, error) {
+	if err := r.db.Create(measurement).Error; err != nil {
+		return nil, err
+	}
+	return measurement, nil
+}
+
+// Read retrieves a single measurement of the user by its ID
+func (r *Repository) Read(userID uuid.UUID, id uuid.UUID) (*Measurement, error) {
+	measurement := &Measurement{}
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(measurement).Error; err != nil {
+		return nil, err // Can be gorm.ErrRecordNotFound
+	}
+	return measurement, nil
+}
+
+// ExistsOnDate reports whether the user has a measurement other than excludeID on the date
+func (r *Repository) ExistsOnDate(userID uuid.UUID, date time.Time, excludeID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&Measurement{}).
+		Where("user_id = ? AND measured_on = ? AND id <> ?", userID, date.Format(DateFormat), excludeID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Update replaces the date and circumferences of a measurement
+func (r *Repository) Update(measurement *Measurement) (int64, error) {
+	result := r.db.Model(&Measurement{}).
+		Select("MeasuredOn", "WaistCm", "NeckCm", "HipsCm", "ChestCm", "ArmCm", "ThighCm", "UpdatedAt").
+		Where("id = ? AND user_id = ?", measurement.ID, measurement.UserID).
+		Updates(measurement)
+	return result.RowsAffected, result.Error
+}
+
+// Delete performs a soft delete on a measurement of the user
+func (r *Repository) Delete(userID uuid.UUID, id uuid.UUID) (int64, error) {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&Measurement{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,115 @@
+package measurement_test
+
+import (
+	"math"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"fitapp-backend/api/resource/measurement"
+	"fitapp-backend/api/resource/user"
+	mockDB "fitapp-backend/mock/db"
+	testUtil "fitapp-backend/util/test"
+)
+
+var measurementColumns = []string{"id", "created_at", "updated_at", "deleted_at", "user_id", "measured_on", "waist_cm", "neck_cm", "hips_cm", "chest_cm", "arm_cm", "thigh_cm"}
+
+func TestRepository_List(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := measurement.NewRepository(db)
+
+	u := &user.User{ID: uuid.New(), Sex: true, Height: 180}
+	to := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	mockRows := sqlmock.NewRows(measurementColumns).
+		AddRow(uuid.New(), time.Now(), time.Now(), nil, u.ID, to.AddDate(0, 0, -14), "85.0", "38.0", nil, "101.5", nil, nil).
+		AddRow(uuid.New(), time.Now(), time.Now(), nil, u.ID, to, nil, nil, nil, nil, "36.5", "58.0")
+
+	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "body_measurements" WHERE user_id = $1 AND measured_on <= $2 AND "body_measurements"."deleted_at" IS NULL ORDER BY measured_on`)
+	mock.ExpectQuery(expectedSQL).WithArgs(u.ID, "2024-03-31").WillReturnRows(mockRows)
+
+	measurements, err := repo.List(u.ID, time.Time{}, to)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 2, len(measurements))
+	dtos := measurements.ToDto(u)
+	testUtil.Equal(t, "2024-03-17", dtos[0].Date)
+	testUtil.Equal(t, 16.1, *dtos[0].BodyFatPercent)
+	testUtil.Equal(t, true, dtos[0].HipsCm == nil)
+	testUtil.Equal(t, true, dtos[1].BodyFatPercent == nil) // No waist and neck
+	testUtil.Equal(t, 36.5, *dtos[1].ArmCm)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Create(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := measurement.NewRepository(db)
+
+	m := &measurement.Measurement{
+		ID:         uuid.New(),
+		UserID:     uuid.New(),
+		MeasuredOn: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC),
+		WaistCm:    decimal.NewNullDecimal(decimal.RequireFromString("72.5")),
+	}
+
+	mock.ExpectBegin()
+	expectedSQL := regexp.QuoteMeta(`INSERT INTO "body_measurements" ("id","created_at","updated_at","deleted_at","user_id","measured_on","waist_cm","neck_cm","hips_cm","chest_cm","arm_cm","thigh_cm") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)`)
+	mock.ExpectExec(expectedSQL).
+		WithArgs(m.ID, mockDB.AnyTime{}, mockDB.AnyTime{}, nil, m.UserID, m.MeasuredOn, "72.5", nil, nil, nil, nil, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	created, err := repo.Create(m)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, m.ID, created.ID)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Delete(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := measurement.NewRepository(db)
+
+	id, userID := uuid.New(), uuid.New()
+	mock.ExpectBegin()
+	expectedSQL := regexp.QuoteMeta(`UPDATE "body_measurements" SET "deleted_at"=$1 WHERE (id = $2 AND user_id = $3) AND "body_measurements"."deleted_at" IS NULL`)
+	mock.ExpectExec(expectedSQL).WithArgs(mockDB.AnyTime{}, id, userID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	rowsAffected, err := repo.Delete(userID, id)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, int64(1), rowsAffected)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestForm_Validate(t *testing.T) {
+	t.Parallel()
+	testUtil.Equal(t, true, (&measurement.Form{}).Validate() != nil) // Nothing measured
+
+	neck := decimal.RequireFromString("9.5")
+	testUtil.Equal(t, true, (&measurement.Form{NeckCm: &neck}).Validate() != nil)
+
+	neck = decimal.RequireFromString("37")
+	testUtil.NoError(t, (&measurement.Form{NeckCm: &neck}).Validate())
+}
+
+func TestNavyBodyFat(t *testing.T) {
+	t.Parallel()
+	bf, err := measurement.NavyBodyFat(true, 180, 85, 38, 0)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 16.1, math.Round(bf*10)/10)
+
+	bf, err = measurement.NavyBodyFat(false, 165, 70, 32, 98)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 26.4, math.Round(bf*10)/10)
+
+	_, err = measurement.NavyBodyFat(false, 165, 70, 32, 0)
+	testUtil.ErrorIs(t, err, measurement.ErrNavyInputs) // Hips are required for women
+}
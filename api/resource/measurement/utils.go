@@ -0,0 +1,139 @@
+package measurement
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"fitapp-backend/api/resource/user"
+)
+
+// DateFormat is the format of measurement dates, matching user dates
+const DateFormat = time.DateOnly
+
+// ErrNavyInputs is returned when the circumferences do not allow a Navy estimate
+var ErrNavyInputs = errors.New("waist and neck (and hips for women) are required, with waist larger than neck")
+
+// NavyBodyFat estimates the body fat percentage with the US Navy circumference
+// method (Hodgdon and Beckett, 1984). All lengths are in centimeters; hips are
+// only used for women.
+func NavyBodyFat(male bool, heightCm, waistCm, neckCm, hipsCm float64) (float64, error) {
+	if heightCm <= 0 || waistCm <= 0 || neckCm <= 0 {
+		return 0, ErrNavyInputs
+	}
+	var density float64
+	if male {
+		if waistCm <= neckCm {
+			return 0, ErrNavyInputs
+		}
+		density = 1.0324 - 0.19077*math.Log10(waistCm-neckCm) + 0.15456*math.Log10(heightCm)
+	} else {
+		if hipsCm <= 0 || waistCm+hipsCm <= neckCm {
+			return 0, ErrNavyInputs
+		}
+		density = 1.29579 - 0.35004*math.Log10(waistCm+hipsCm-neckCm) + 0.22100*math.Log10(heightCm)
+	}
+	return 495/density - 450, nil
+}
+
+// BodyFat estimates the user's body fat percentage from the measurement
+func (m *Measurement) BodyFat(u *user.User) (float64, error) {
+	return NavyBodyFat(u.Sex, float64(u.Height),
+		m.WaistCm.Decimal.InexactFloat64(), m.NeckCm.Decimal.InexactFloat64(), m.HipsCm.Decimal.InexactFloat64())
+}
+
+// ToDto converts a Measurement to its DTO representation, estimating the body
+// fat of the user it belongs to
+func (m *Measurement) ToDto(u *user.User) *DTO {
+	dto := &DTO{
+		ID:      m.ID.String(),
+		UserID:  m.UserID.String(),
+		Date:    m.MeasuredOn.Format(DateFormat),
+		WaistCm: nullFloat(m.WaistCm),
+		NeckCm:  nullFloat(m.NeckCm),
+		HipsCm:  nullFloat(m.HipsCm),
+		ChestCm: nullFloat(m.ChestCm),
+		ArmCm:   nullFloat(m.ArmCm),
+		ThighCm: nullFloat(m.ThighCm),
+	}
+	if bf, err := m.BodyFat(u); err == nil {
+		bf = math.Round(bf*10) / 10
+		dto.BodyFatPercent = &bf
+	}
+	return dto
+}
+
+// ToDto converts a slice of Measurements of the user to a slice of DTOs
+func (ms Measurements) ToDto(u *user.User) []*DTO {
+	dtos := make([]*DTO, len(ms))
+	for i, m := range ms {
+		dtos[i] = m.ToDto(u)
+	}
+	return dtos
+}
+
+// nullFloat converts an optional decimal, returning nil if it is not set
+func nullFloat(d decimal.NullDecimal) *float64 {
+	if !d.Valid {
+		return nil
+	}
+	f := d.Decimal.InexactFloat64()
+	return &f
+}
+
+// Validate checks that at least one circumference is given and all are in range
+func (f *Form) Validate() error {
+	given := 0
+	for _, c := range f.circumferences() {
+		if c.value == nil {
+			continue
+		}
+		given++
+		if c.value.LessThan(MinCircumferenceCm) || c.value.GreaterThan(MaxCircumferenceCm) {
+			return fmt.Errorf("%s must be between %s and %s", c.name, MinCircumferenceCm, MaxCircumferenceCm)
+		}
+	}
+	if given == 0 {
+		return errors.New("at least one circumference is required")
+	}
+	return nil
+}
+
+// circumferences lists the form values with their JSON names
+func (f *Form) circumferences() []struct {
+	name  string
+	value *decimal.Decimal
+} {
+	return []struct {
+		name  string
+		value *decimal.Decimal
+	}{
+		{"waist_cm", f.WaistCm},
+		{"neck_cm", f.NeckCm},
+		{"hips_cm", f.HipsCm},
+		{"chest_cm", f.ChestCm},
+		{"arm_cm", f.ArmCm},
+		{"thigh_cm", f.ThighCm},
+	}
+}
+
+// apply copies the form's circumferences to the measurement, clearing omitted ones
+func (f *Form) apply(m *Measurement) {
+	m.WaistCm = nullDecimal(f.WaistCm)
+	m.NeckCm = nullDecimal(f.NeckCm)
+	m.HipsCm = nullDecimal(f.HipsCm)
+	m.ChestCm = nullDecimal(f.ChestCm)
+	m.ArmCm = nullDecimal(f.ArmCm)
+	m.ThighCm = nullDecimal(f.ThighCm)
+}
+
+// nullDecimal converts an optional form value
+func nullDecimal(d *decimal.Decimal) decimal.NullDecimal {
+	if d == nil {
+		return decimal.NullDecimal{}
+	}
+	return decimal.NewNullDecimal(*d)
+}
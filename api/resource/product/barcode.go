@@ -0,0 +1,53 @@
+package product
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidBarcode is returned for codes that are not valid EAN-8, UPC-A or EAN-13
+var ErrInvalidBarcode = errors.New("invalid barcode: expected EAN-8, UPC-A or EAN-13 with a valid check digit")
+
+// barcodeLength is the length of the canonical GTIN-13 form stored in the database
+const barcodeLength = 13
+
+// NormalizeBarcode validates an EAN-8, UPC-A or EAN-13 code and returns it
+// left-padded with zeros to 13 digits, so a UPC-A code and its EAN-13
+// equivalent (leading 0) resolve to the same product.
+func NormalizeBarcode(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	code = strings.NewReplacer(" ", "", "-", "").Replace(code)
+
+	switch len(code) {
+	case 8, 12, 13:
+	default:
+		return "", ErrInvalidBarcode
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return "", ErrInvalidBarcode
+		}
+	}
+
+	normalized := strings.Repeat("0", barcodeLength-len(code)) + code
+	if !validCheckDigit(normalized) {
+		return "", ErrInvalidBarcode
+	}
+	return normalized, nil
+}
+
+// validCheckDigit verifies the GS1 mod-10 check digit of a zero-padded GTIN.
+// Leading zeros do not change the checksum, so the padded form can be checked directly.
+func validCheckDigit(code string) bool {
+	sum := 0
+	// Weights alternate 3,1,3,... starting from the digit left of the check digit
+	for i := len(code) - 2; i >= 0; i-- {
+		digit := int(code[i] - '0')
+		if (len(code)-2-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	check := (10 - sum%10) % 10
+	return check == int(code[len(code)-1]-'0')
+}
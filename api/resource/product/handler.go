@@ -2,29 +2,31 @@ package product
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 
 	// Assuming a shared error handling package exists
 	// "fitapp-backend/internal/err"
-	userday "fitapp-backend/api/resource/user_day"
+	"fitapp-backend/api/resource/common/caller"
 )
 
 // API holds the dependencies for the product handlers
 type API struct {
-	repository   *Repository
-	user_day_api *userday.API
+	repository *Repository
 }
 
 // New creates a new API instance for product routes
-func New(db *gorm.DB, user_day_api *userday.API) *API {
+func New(db *gorm.DB) *API {
 	return &API{
-		repository:   NewRepository(db),
-		user_day_api: user_day_api,
+		repository: NewRepository(db),
 	}
 }
 
@@ -43,33 +45,206 @@ func enableCors(w *http.ResponseWriter) {
 
 // --- TODO: Implement proper validation ---
 
+// readVisible loads a product the caller may see. Private products of other
+// users are reported as not found. Returns nil if an error response has been written.
+func (a *API) readVisible(w http.ResponseWriter, r *http.Request, id uuid.UUID) *Product {
+	product, err := a.repository.Read(id)
+	if err == nil && !canView(r, product) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			handleErr(w, http.StatusNotFound, "Product not found", err)
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to read product", err)
+		}
+		return nil
+	}
+	return product
+}
+
+// readModifiable loads a product the caller may update or delete.
+// Returns nil if an error response has been written.
+func (a *API) readModifiable(w http.ResponseWriter, r *http.Request, id uuid.UUID) *Product {
+	c, ok := caller.FromRequest(r)
+	if !ok {
+		handleErr(w, http.StatusUnauthorized, "Caller not identified", nil)
+		return nil
+	}
+	product := a.readVisible(w, r, id)
+	if product == nil {
+		return nil
+	}
+	if !product.ModifiableBy(c) {
+		handleErr(w, http.StatusForbidden, "Only the owner or an admin may modify this product", nil)
+		return nil
+	}
+	return product
+}
+
+// canView reports whether the caller of r may see the product
+func canView(r *http.Request, product *Product) bool {
+	c, ok := caller.FromRequest(r)
+	if !ok {
+		return product.VisibleTo(uuid.Nil)
+	}
+	return c.Admin || product.VisibleTo(c.UserID)
+}
+
+// applyVisibility validates the form's visibility and sets it on the product,
+// keeping current when the form leaves it empty. Returns false if an error response has been written.
+func applyVisibility(w http.ResponseWriter, c *caller.Caller, form *Form, product *Product, current string) bool {
+	visibility := form.Visibility
+	if visibility == "" {
+		visibility = current
+	}
+	if !ValidVisibility(visibility) {
+		handleErr(w, http.StatusUnprocessableEntity, "visibility must be one of: private, shared, global", nil)
+		return false
+	}
+	if visibility == VisibilityGlobal && !c.Admin {
+		handleErr(w, http.StatusForbidden, "Only admins may publish verified global products", nil)
+		return false
+	}
+	if visibility != VisibilityGlobal && product.OwnerID == nil {
+		handleErr(w, http.StatusUnprocessableEntity, "Catalog products without an owner must stay global", nil)
+		return false
+	}
+	product.Visibility = visibility
+	return true
+}
+
+// applyBarcode normalizes the form's optional barcode onto the product and makes
+// sure no other product in its barcode scope uses it. Returns false if an error response has been written.
+func (a *API) applyBarcode(w http.ResponseWriter, form *Form, product *Product) bool {
+	if form.Barcode == "" {
+		return true
+	}
+
+	barcode, err := NormalizeBarcode(form.Barcode)
+	if err != nil {
+		handleErr(w, http.StatusUnprocessableEntity, err.Error(), err)
+		return false
+	}
+
+	existing, err := a.repository.ReadByOwnerBarcode(barcode, product.OwnerID)
+	if err == nil && existing.ID != product.ID {
+		handleErr(w, http.StatusConflict, "Another product already uses this barcode", nil)
+		return false
+	}
+	if err != nil && err != gorm.ErrRecordNotFound {
+		handleErr(w, http.StatusInternalServerError, "Failed to check barcode", err)
+		return false
+	}
+
+	product.Barcode = &barcode
+	return true
+}
+
+// parseSearchParams reads the search, filter, sort and pagination query parameters
+func parseSearchParams(r *http.Request) (*SearchParams, error) {
+	q := r.URL.Query()
+	params := &SearchParams{
+		Query:  strings.TrimSpace(q.Get("q")),
+		Ranges: map[string]Range{},
+		Sort:   q.Get("sort"),
+		Mine:   q.Get("mine") == "true",
+	}
+
+	if params.Sort != "" && !ValidSort(params.Sort) {
+		return nil, fmt.Errorf("invalid sort %q", params.Sort)
+	}
+	if params.Sort == SortRelevance && params.Query == "" {
+		return nil, errors.New("sort=relevance requires q")
+	}
+
+	for _, column := range NutrientColumns {
+		var rng Range
+		for bound, target := range map[string]**decimal.Decimal{"min_": &rng.Min, "max_": &rng.Max} {
+			raw := q.Get(bound + column)
+			if raw == "" {
+				continue
+			}
+			value, err := decimal.NewFromString(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s%s: %w", bound, column, err)
+			}
+			*target = &value
+		}
+		if rng.Min != nil || rng.Max != nil {
+			params.Ranges[column] = rng
+		}
+	}
+
+	if raw := q.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > MaxSearchLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", MaxSearchLimit)
+		}
+		params.Limit = limit
+	}
+
+	if raw := q.Get("cursor"); raw != "" {
+		cursor, err := DecodeCursor(raw)
+		if err != nil {
+			return nil, err
+		}
+		if !cursor.Matches(params) {
+			return nil, ErrInvalidCursor
+		}
+		params.Cursor = cursor
+	}
+
+	return params, nil
+}
+
 // List godoc
 //
 //	@summary		List products
-//	@description	List all available products
+//	@description	Search the caller's own products merged with the public catalog by name (fuzzy, case and diacritic insensitive) with nutrient filters, sorting and cursor pagination
 //	@tags			products
 //	@accept			json
 //	@produce		json
-//	@success		200	{array}		DTO
+//	@param			q				query		string	false	"Name search"
+//	@param			min_kcal		query		number		false	"Minimum kcal per 100 g (also min_/max_ proteins, carbs, fats)"
+//	@param			max_kcal		query		number		false	"Maximum kcal per 100 g"
+//	@param			sort			query		string	false	"relevance (default with q), name (default), kcal, proteins, carbs, fats; prefix with - for descending"
+//	@param			limit			query		int		false	"Page size (default 20, max 100)"
+//	@param			cursor			query		string	false	"next_cursor from the previous page"
+//	@param			mine			query		bool	false	"Only the caller's own products"
+//	@success		200	{object}	ListDTO
+//	@failure		400	{object}	string "Bad Request" // Invalid filter, sort or cursor
+//	@failure		401	{object}	string "Unauthorized" // mine=true without an identified caller
 //	@failure		500	{object}	string "Internal Server Error"
 //	@router			/products [get]
 func (a *API) List(w http.ResponseWriter, r *http.Request) {
 	enableCors(&w)
-	products, err := a.repository.List()
+	params, err := parseSearchParams(r)
 	if err != nil {
-		handleErr(w, http.StatusInternalServerError, "Failed to retrieve products", err)
+		handleErr(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
-	// Return empty array explicitly if no products, otherwise GORM might return null
-	if len(products) == 0 {
-		w.Header().Set("Content-Type", "application/json")
-		fmt.Fprint(w, "[]")
+	if c, ok := caller.FromRequest(r); ok {
+		params.Viewer = c.UserID
+	} else if params.Mine {
+		handleErr(w, http.StatusUnauthorized, "Caller not identified", nil)
 		return
 	}
 
+	products, next, err := a.repository.Search(params)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to retrieve products", err)
+		return
+	}
+
+	page := &ListDTO{Products: products.ToDto()}
+	if next != nil {
+		page.NextCursor = next.Encode()
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(products.ToDto()); err != nil {
+	if err := json.NewEncoder(w).Encode(page); err != nil {
 		handleErr(w, http.StatusInternalServerError, "Failed to encode products response", err)
 		return
 	}
@@ -78,26 +253,39 @@ func (a *API) List(w http.ResponseWriter, r *http.Request) {
 // Create godoc
 //
 //	@summary		Create product
-//	@description	Create a new product
+//	@description	Create a new product owned by the caller; only admins may create verified global products
 //	@tags			products
 //	@accept			json
 //	@produce		json
 //	@param			body	body	Form	true	"Product form"
 //	@success		201
 //	@failure		400	{object}	string "Bad Request" // e.g., Invalid JSON
-//	@failure		422	{object}	string "Unprocessable Entity" // e.g., Validation errors
+//	@failure		401	{object}	string "Unauthorized" // Caller not identified
+//	@failure		403	{object}	string "Forbidden" // Non-admin publishing a global product
+//	@failure		409	{object}	string "Conflict" // Barcode already used by another product of the same owner
+//	@failure		422	{object}	string "Unprocessable Entity" // e.g., Validation errors, invalid barcode
 //	@failure		500	{object}	string "Internal Server Error"
 //	@router			/products [post]
 func (a *API) Create(w http.ResponseWriter, r *http.Request) {
 	enableCors(&w)
+	c, ok := caller.FromRequest(r)
+	if !ok {
+		handleErr(w, http.StatusUnauthorized, "Caller not identified", nil)
+		return
+	}
+
 	form := &Form{}
 	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
 		handleErr(w, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
 
-	if form.Grams == 0 {
-		http.Error(w, "Grams must be greater than zero", http.StatusBadRequest)
+	if form.BaseGrams() <= 0 {
+		http.Error(w, "Grams (or milliliters with density) must be greater than zero", http.StatusBadRequest)
+		return
+	}
+	if err := form.Nutrients.Validate(); err != nil {
+		handleErr(w, http.StatusUnprocessableEntity, err.Error(), err)
 		return
 	}
 
@@ -106,15 +294,15 @@ func (a *API) Create(w http.ResponseWriter, r *http.Request) {
 
 	newProduct := form.ToModel()
 	newProduct.ID = uuid.New()
-
-	// // Obliczenie wartości w przeliczeniu na 100g z użyciem float64
-	// kcal := int(float64(form.Kcal) * 100 / float64(form.Grams))
-	// proteins := int(float64(form.Proteins) * 100 / float64(form.Grams))
-	// carbs := int(float64(form.Carbs) * 100 / float64(form.Grams))
-	// fats := int(float64(form.Fats) * 100 / float64(form.Grams))
-
-	// // Wstawienie do user_day
-	// a.user_day_api.Create_from_product(form.UserID, kcal, proteins, carbs, fats)
+	if form.Visibility != VisibilityGlobal {
+		newProduct.OwnerID = &c.UserID // Verified catalog products have no owner
+	}
+	if !applyVisibility(w, c, form, newProduct, VisibilityPrivate) {
+		return
+	}
+	if !a.applyBarcode(w, form, newProduct) {
+		return
+	}
 
 	_, err := a.repository.Create(newProduct)
 	if err != nil {
@@ -153,7 +341,44 @@ func (a *API) Read(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	product, err := a.repository.Read(id)
+	product := a.readVisible(w, r, id)
+	if product == nil {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(product.ToDto()); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode product response", err)
+		return
+	}
+}
+
+// ReadByBarcode godoc
+//
+//	@summary		Read product by barcode
+//	@description	Look up a product by its EAN-8, UPC-A or EAN-13 barcode; equivalent UPC-A/EAN-13 codes match the same product. The caller's own product wins over the catalog, which wins over products shared by others
+//	@tags			products
+//	@accept			json
+//	@produce		json
+//	@param			code	path		string	true	"Barcode digits"
+//	@success		200	{object}	DTO
+//	@failure		400	{object}	string "Bad Request" // Invalid barcode or check digit
+//	@failure		404	{object}	string "Not Found"
+//	@failure		500	{object}	string "Internal Server Error"
+//	@router			/products/barcode/{code} [get]
+func (a *API) ReadByBarcode(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	barcode, err := NormalizeBarcode(chi.URLParam(r, "code"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	viewer := uuid.Nil
+	if c, ok := caller.FromRequest(r); ok {
+		viewer = c.UserID
+	}
+	product, err := a.repository.ReadByBarcode(barcode, viewer)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			handleErr(w, http.StatusNotFound, "Product not found", err)
@@ -173,7 +398,7 @@ func (a *API) Read(w http.ResponseWriter, r *http.Request) {
 // Update godoc
 //
 //	@summary		Update product
-//	@description	Update an existing product by ID
+//	@description	Update an existing product by ID; only its owner or an admin may do so
 //	@tags			products
 //	@accept			json
 //	@produce		json
@@ -181,8 +406,11 @@ func (a *API) Read(w http.ResponseWriter, r *http.Request) {
 //	@param			body	body	Form	true	"Product form"
 //	@success		200		"Successfully updated" // Indicate success, maybe return updated object?
 //	@failure		400	{object}	string "Bad Request" // e.g., Invalid UUID format or JSON
+//	@failure		401	{object}	string "Unauthorized" // Caller not identified
+//	@failure		403	{object}	string "Forbidden" // Caller is neither owner nor admin
 //	@failure		404	{object}	string "Not Found" // Product ID does not exist
-//	@failure		422	{object}	string "Unprocessable Entity" // e.g., Validation errors
+//	@failure		409	{object}	string "Conflict" // Barcode already used by another product of the same owner
+//	@failure		422	{object}	string "Unprocessable Entity" // e.g., Validation errors, invalid barcode
 //	@failure		500	{object}	string "Internal Server Error"
 //	@router			/products/{id} [put]
 func (a *API) Update(w http.ResponseWriter, r *http.Request) {
@@ -205,10 +433,32 @@ func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if form.BaseGrams() <= 0 {
+		http.Error(w, "Grams (or milliliters with density) must be greater than zero", http.StatusBadRequest)
+		return
+	}
+	if err := form.Nutrients.Validate(); err != nil {
+		handleErr(w, http.StatusUnprocessableEntity, err.Error(), err)
+		return
+	}
+
 	// --- TODO: Add validation for the form data ---
 
+	existing := a.readModifiable(w, r, id)
+	if existing == nil {
+		return
+	}
+	c, _ := caller.FromRequest(r)
+
 	product := form.ToModel()
 	product.ID = id // Set the ID from the path parameter
+	product.OwnerID = existing.OwnerID
+	if !applyVisibility(w, c, form, product, existing.Visibility) {
+		return
+	}
+	if !a.applyBarcode(w, form, product) {
+		return
+	}
 
 	rowsAffected, err := a.repository.Update(product)
 	if err != nil {
@@ -233,13 +483,15 @@ func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 // Delete godoc
 //
 //	@summary		Delete product
-//	@description	Soft delete a product by ID
+//	@description	Soft delete a product by ID; only its owner or an admin may do so
 //	@tags			products
 //	@accept			json
 //	@produce		json
 //	@param			id	path	string	true	"Product ID (UUID)"
 //	@success		200		"Successfully deleted" // Indicate success
 //	@failure		400	{object}	string "Bad Request" // e.g., Invalid UUID format
+//	@failure		401	{object}	string "Unauthorized" // Caller not identified
+//	@failure		403	{object}	string "Forbidden" // Caller is neither owner nor admin
 //	@failure		404	{object}	string "Not Found" // Product ID does not exist or already deleted
 //	@failure		500	{object}	string "Internal Server Error"
 //	@router			/products/{id} [delete]
@@ -257,6 +509,10 @@ func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if a.readModifiable(w, r, id) == nil {
+		return
+	}
+
 	rowsAffected, err := a.repository.Delete(id)
 	if err != nil {
 		handleErr(w, http.StatusInternalServerError, "Failed to delete product", err)
@@ -272,3 +528,292 @@ func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprint(w, "Product deleted successfully") // Or return JSON
 }
+
+// ListServings godoc
+//
+//	@summary		List product servings
+//	@description	List the named serving sizes of a product with their weight in grams
+//	@tags			products
+//	@accept			json
+//	@produce		json
+//	@param			id	path		string	true	"Product ID (UUID)"
+//	@success		200	{array}		ServingDTO
+//	@failure		400	{object}	string "Bad Request"
+//	@failure		404	{object}	string "Not Found"
+//	@failure		500	{object}	string "Internal Server Error"
+//	@router			/products/{id}/servings [get]
+func (a *API) ListServings(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid UUID format for id parameter", err)
+		return
+	}
+
+	product := a.readVisible(w, r, id)
+	if product == nil {
+		return
+	}
+
+	servings, err := a.repository.ListServings(id)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to retrieve servings", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(servings.ToDto(product)); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode servings response", err)
+		return
+	}
+}
+
+// CreateServing godoc
+//
+//	@summary		Add product serving
+//	@description	Add a named serving size (e.g. "slice" = 30 g, "cup" = 240 ml) to a product
+//	@tags			products
+//	@accept			json
+//	@produce		json
+//	@param			id		path	string		true	"Product ID (UUID)"
+//	@param			body	body	ServingForm	true	"Serving form"
+//	@success		201	{object}	ServingDTO
+//	@failure		400	{object}	string "Bad Request"
+//	@failure		401	{object}	string "Unauthorized"
+//	@failure		403	{object}	string "Forbidden"
+//	@failure		404	{object}	string "Not Found"
+//	@failure		422	{object}	string "Unprocessable Entity"
+//	@failure		500	{object}	string "Internal Server Error"
+//	@router			/products/{id}/servings [post]
+func (a *API) CreateServing(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid UUID format for id parameter", err)
+		return
+	}
+
+	form := &ServingForm{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	name := NormalizeServingName(form.ServingName)
+	if name == "" {
+		handleErr(w, http.StatusUnprocessableEntity, "serving_name is required", nil)
+		return
+	}
+	if form.Amount <= 0 {
+		handleErr(w, http.StatusUnprocessableEntity, "amount must be greater than zero", nil)
+		return
+	}
+
+	product := a.readModifiable(w, r, id)
+	if product == nil {
+		return
+	}
+
+	unit := form.Unit
+	if unit == "" {
+		unit = UnitGrams
+	}
+	if _, err := product.ToGrams(form.Amount, unit); err != nil {
+		handleErr(w, http.StatusUnprocessableEntity, err.Error(), err)
+		return
+	}
+
+	if _, err := a.repository.FindServing(id, name); err == nil {
+		handleErr(w, http.StatusUnprocessableEntity, "Serving with this name already exists", nil)
+		return
+	} else if err != gorm.ErrRecordNotFound {
+		handleErr(w, http.StatusInternalServerError, "Failed to check existing servings", err)
+		return
+	}
+
+	serving := &Serving{
+		ID:          uuid.New(),
+		ProductID:   id,
+		ServingName: name,
+		Amount:      form.Amount,
+		Unit:        unit,
+	}
+	if _, err := a.repository.CreateServing(serving); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to create serving", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(serving.ToDto(product))
+}
+
+// DeleteServing godoc
+//
+//	@summary		Delete product serving
+//	@description	Soft delete a named serving size of a product
+//	@tags			products
+//	@accept			json
+//	@produce		json
+//	@param			id			path	string	true	"Product ID (UUID)"
+//	@param			servingId	path	string	true	"Serving ID (UUID)"
+//	@success		200		"Successfully deleted"
+//	@failure		400	{object}	string "Bad Request"
+//	@failure		401	{object}	string "Unauthorized"
+//	@failure		403	{object}	string "Forbidden"
+//	@failure		404	{object}	string "Not Found"
+//	@failure		500	{object}	string "Internal Server Error"
+//	@router			/products/{id}/servings/{servingId} [delete]
+func (a *API) DeleteServing(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid UUID format for id parameter", err)
+		return
+	}
+
+	servingID, err := uuid.Parse(chi.URLParam(r, "servingId"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid UUID format for servingId parameter", err)
+		return
+	}
+
+	if a.readModifiable(w, r, id) == nil {
+		return
+	}
+
+	rowsAffected, err := a.repository.DeleteServing(id, servingID)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to delete serving", err)
+		return
+	}
+
+	if rowsAffected == 0 {
+		handleErr(w, http.StatusNotFound, "Serving not found or already deleted", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "Serving deleted successfully")
+}
+
+// Moderate godoc
+//
+//	@summary		Moderate product visibility (admin)
+//	@description	Publish a shared product to the verified global catalog, or withdraw a global product back to its owner
+//	@tags			admin
+//	@accept			json
+//	@produce		json
+//	@security		BearerAuth
+//	@param			id		path		string			true	"Product ID (UUID)"
+//	@param			body	body		VisibilityForm	true	"Visibility form"
+//	@success		200	{object}	DTO "Returns the moderated product"
+//	@failure		400	{object}	string "Bad Request"
+//	@failure		403	{object}	string "Forbidden"
+//	@failure		404	{object}	string "Not Found"
+//	@failure		422	{object}	string "Unprocessable Entity" // Unknown visibility, or a catalog product without owner made non-global
+//	@failure		500	{object}	string "Internal Server Error"
+//	@router			/admin/products/{id}/visibility [put]
+func (a *API) Moderate(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid UUID format for id parameter", err)
+		return
+	}
+
+	form := &VisibilityForm{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if form.Visibility == "" {
+		handleErr(w, http.StatusBadRequest, "visibility is required", nil)
+		return
+	}
+
+	c, ok := caller.FromRequest(r)
+	if !ok {
+		handleErr(w, http.StatusUnauthorized, "Caller not identified", nil)
+		return
+	}
+	product := a.readModifiable(w, r, id)
+	if product == nil {
+		return
+	}
+	if !applyVisibility(w, c, &Form{Visibility: form.Visibility}, product, product.Visibility) {
+		return
+	}
+
+	if _, err := a.repository.SetVisibility(id, product.Visibility); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to change product visibility", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(product.ToDto()); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode product response", err)
+		return
+	}
+}
+
+// Restore godoc
+//
+//	@summary		Restore product (admin)
+//	@description	Undo the soft delete of a product, unless its barcode has been given to another product since
+//	@tags			admin
+//	@accept			json
+//	@produce		json
+//	@security		BearerAuth
+//	@param			id	path		string	true	"Product ID (UUID)"
+//	@success		200	{object}	DTO "Returns the restored product"
+//	@failure		400	{object}	string "Bad Request"
+//	@failure		403	{object}	string "Forbidden"
+//	@failure		404	{object}	string "Not Found" // No deleted product with this ID
+//	@failure		409	{object}	string "Conflict" // Barcode used by another product of the same owner
+//	@failure		500	{object}	string "Internal Server Error"
+//	@router			/admin/products/{id}/restore [post]
+func (a *API) Restore(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid UUID format for id parameter", err)
+		return
+	}
+
+	product, err := a.repository.ReadDeleted(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			handleErr(w, http.StatusNotFound, "Deleted product not found", err)
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to read product", err)
+		}
+		return
+	}
+	if product.Barcode != nil {
+		if _, err := a.repository.ReadByOwnerBarcode(*product.Barcode, product.OwnerID); err == nil {
+			handleErr(w, http.StatusConflict, "Barcode already used by another product", nil)
+			return
+		} else if err != gorm.ErrRecordNotFound {
+			handleErr(w, http.StatusInternalServerError, "Failed to check barcode", err)
+			return
+		}
+	}
+
+	rowsAffected, err := a.repository.Restore(id)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to restore product", err)
+		return
+	}
+	if rowsAffected == 0 {
+		handleErr(w, http.StatusNotFound, "Deleted product not found", nil)
+		return
+	}
+	product.DeletedAt = gorm.DeletedAt{}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(product.ToDto()); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode product response", err)
+		return
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+
+	"fitapp-backend/api/resource/common/nutrient"
 )
 
 // Product represents the structure of the 'products' table
@@ -12,34 +15,108 @@ type Product struct {
 	ID          uuid.UUID `gorm:"type:uuid;primarykey"`
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
-	DeletedAt   gorm.DeletedAt `gorm:"index"`
-	ProductName string         `gorm:"not null"`
-	Kcal        int            `gorm:"not null"`
-	Proteins    int            `gorm:"not null"`
-	Carbs       int            `gorm:"not null"`
-	Fats        int            `gorm:"not null"`
+	DeletedAt   gorm.DeletedAt  `gorm:"index"`
+	ProductName string          `gorm:"not null"`
+	Kcal        decimal.Decimal `gorm:"type:numeric(10,2);not null"` // per 100 g
+	Proteins    decimal.Decimal `gorm:"type:numeric(10,2);not null"`
+	Carbs       decimal.Decimal `gorm:"type:numeric(10,2);not null"`
+	Fats        decimal.Decimal `gorm:"type:numeric(10,2);not null"`
+	Density     *float64        // grams per millilitre, needed for volume units
+	Barcode     *string         // normalized GTIN-13, see NormalizeBarcode
+	Nutrients   nutrient.Set    `gorm:"type:jsonb;not null"` // extended profile, per 100 g
+	OwnerID     *uuid.UUID      `gorm:"type:uuid;index"`     // nil for catalog products without an owner
+	Visibility  string          `gorm:"not null"`
 }
 
 // Products is a slice of Product pointers
 type Products []*Product
 
+// Visibility levels of a product
+const (
+	VisibilityPrivate = "private" // Only the owner sees it
+	VisibilityShared  = "shared"  // Everyone sees it, contributed by a user
+	VisibilityGlobal  = "global"  // Verified catalog entry, only admins modify it
+)
+
+// PublicVisibilities are the levels visible to every user
+var PublicVisibilities = []string{VisibilityShared, VisibilityGlobal}
+
+// Units a serving amount can be expressed in
+const (
+	UnitGrams       = "g"
+	UnitMilliliters = "ml"
+)
+
+// Serving represents a named portion of a product in the 'product_servings' table,
+// e.g. "slice" = 30 g or "cup" = 240 ml
+type Serving struct {
+	ID        uuid.UUID `gorm:"type:uuid;primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	ProductID   uuid.UUID `gorm:"type:uuid;not null"`
+	ServingName string    `gorm:"not null"`
+	Amount      float64   `gorm:"not null"`
+	Unit        string    `gorm:"not null"`
+}
+
+// TableName overrides the inferred 'servings' table name
+func (Serving) TableName() string {
+	return "product_servings"
+}
+
+// Servings is a slice of Serving pointers
+type Servings []*Serving
+
 // DTO represents the data transfer object for a Product
 type DTO struct {
-	ID          string `json:"id"`
-	ProductName string `json:"product_name"`
-	Kcal        int    `json:"kcal"`
-	Proteins    int    `json:"proteins"`
-	Carbs       int    `json:"carbs"`
-	Fats        int    `json:"fats"`
+	ID          string             `json:"id"`
+	ProductName string             `json:"product_name"`
+	Kcal        float64            `json:"kcal"`     // Rounded per nutrient.Kcal
+	Proteins    float64            `json:"proteins"` // Rounded per nutrient.Macro
+	Carbs       float64            `json:"carbs"`
+	Fats        float64            `json:"fats"`
+	Density     *float64           `json:"density,omitempty"`
+	Barcode     *string            `json:"barcode,omitempty"`
+	Nutrients   map[string]float64 `json:"nutrients,omitempty"` // Extended profile keyed by nutrient code
+	OwnerID     string             `json:"owner_id,omitempty"`
+	Visibility  string             `json:"visibility"`
+}
+
+// ServingDTO represents the data transfer object for a Serving
+type ServingDTO struct {
+	ID          string  `json:"id"`
+	ServingName string  `json:"serving_name"`
+	Amount      float64 `json:"amount"`
+	Unit        string  `json:"unit"`
+	Grams       float64 `json:"grams"` // Amount converted to grams
+}
+
+// VisibilityForm represents the data structure for moderating a Product's visibility
+type VisibilityForm struct {
+	Visibility string `json:"visibility"` // "private", "shared" or "global"
+}
+
+// ServingForm represents the data structure for adding a Serving
+type ServingForm struct {
+	ServingName string  `json:"serving_name"`
+	Amount      float64 `json:"amount"`
+	Unit        string  `json:"unit"` // "g" or "ml"
 }
 
 // Form represents the data structure for creating/updating a Product
 type Form struct {
-	UserID      string `json:"user_id"`
-	ProductName string `json:"product_name"`
-	Grams       int    `json:"grams"`
-	Kcal        int    `json:"kcal"`
-	Proteins    int    `json:"proteins"`
-	Carbs       int    `json:"carbs"`
-	Fats        int    `json:"fats"`
+	UserID      string          `json:"user_id"`
+	ProductName string          `json:"product_name"`
+	Grams       int             `json:"grams"`       // Amount the values below refer to, in grams...
+	Milliliters int             `json:"milliliters"` // ...or in millilitres (requires density)
+	Density     *float64        `json:"density"`     // Optional, grams per millilitre
+	Barcode     string          `json:"barcode"`     // Optional, EAN-8, UPC-A or EAN-13
+	Kcal        decimal.Decimal `json:"kcal"`
+	Proteins    decimal.Decimal `json:"proteins"`
+	Carbs       decimal.Decimal `json:"carbs"`
+	Fats        decimal.Decimal `json:"fats"`
+	Nutrients   nutrient.Set    `json:"nutrients"`  // Optional, extended profile keyed by nutrient code
+	Visibility  string          `json:"visibility"` // "private" (default), "shared" or "global" (admins only)
 }
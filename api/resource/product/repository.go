@@ -1,10 +1,32 @@
 package product
 
 import (
+	"fmt"
+	"strings"
+	"sync"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
 )
 
+// maxBindParameters is the PostgreSQL limit of bind parameters per statement
+const maxBindParameters = 65535
+
+// MaxImportBatch is the largest batch ImportBatch accepts: its INSERT binds one
+// parameter per column of every product
+var MaxImportBatch = maxBindParameters / productColumnCount()
+
+// productColumnCount returns the number of columns GORM writes for a product
+func productColumnCount() int {
+	s, err := schema.Parse(&Product{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		panic(err)
+	}
+	return len(s.DBNames)
+}
+
 // Repository handles database operations for products
 type Repository struct {
 	db *gorm.DB
@@ -27,6 +49,126 @@ func (r *Repository) List() (Products, error) {
 	return products, nil
 }
 
+// searchRow carries the relevance score next to the product for cursor building
+type searchRow struct {
+	Product
+	Score float64
+}
+
+// Search retrieves a page of non-deleted products matching the parameters.
+// Returns the cursor of the next page, or nil if this is the last one.
+func (r *Repository) Search(params *SearchParams) (Products, *Cursor, error) {
+	query := r.db.Model(&Product{})
+	if params.Mine {
+		query = query.Where("owner_id = ?", params.Viewer)
+	} else {
+		query = query.Where("visibility IN ? OR owner_id = ?", PublicVisibilities, params.Viewer)
+	}
+	normalizedName := "f_unaccent(lower(product_name))"
+	scoreExpr, scoreArgs := "0", []interface{}{}
+
+	if params.Query != "" {
+		// Trigram similarity for typos, substring match for short or partial queries
+		query = query.Where(
+			normalizedName+" % f_unaccent(lower(?)) OR strpos("+normalizedName+", f_unaccent(lower(?))) > 0",
+			params.Query, params.Query,
+		)
+		scoreExpr = "similarity(" + normalizedName + ", f_unaccent(lower(?)))"
+		scoreArgs = []interface{}{params.Query}
+	}
+
+	for _, column := range NutrientColumns {
+		rng, ok := params.Ranges[column]
+		if !ok {
+			continue
+		}
+		if rng.Min != nil {
+			query = query.Where(column+" >= ?", *rng.Min)
+		}
+		if rng.Max != nil {
+			query = query.Where(column+" <= ?", *rng.Max)
+		}
+	}
+
+	sortKey := params.SortKey()
+	// Relevance is best-first; other keys are ascending unless prefixed with "-"
+	desc := strings.HasPrefix(sortKey, "-") || sortKey == SortRelevance
+	sortExpr, sortArgs := sortColumns[strings.TrimPrefix(sortKey, "-")], []interface{}{}
+	if sortKey == SortRelevance {
+		sortExpr, sortArgs = scoreExpr, scoreArgs
+	}
+	direction, comparison := "ASC", ">"
+	if desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if params.Cursor != nil {
+		// Rows strictly after the cursor, ties on the sort value broken by ID
+		var args []interface{}
+		args = append(args, sortArgs...)
+		args = append(args, params.Cursor.Value)
+		args = append(args, sortArgs...)
+		args = append(args, params.Cursor.Value, params.Cursor.ID)
+		query = query.Where(
+			fmt.Sprintf("(%[1]s %[2]s ?) OR (%[1]s = ? AND products.id > ?)", sortExpr, comparison),
+			args...,
+		)
+	}
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	rows := make([]*searchRow, 0, limit+1)
+	err := query.
+		Select("products.*, "+scoreExpr+" AS score", scoreArgs...).
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                sortExpr + " " + direction + ", products.id ASC",
+			Vars:               sortArgs,
+			WithoutParentheses: true,
+		}}).
+		Limit(limit + 1). // One extra row tells whether there is a next page
+		Find(&rows).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var next *Cursor
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[limit-1]
+		next = &Cursor{ID: last.ID, Value: last.sortValue(sortKey), Sort: sortKey, Query: params.Query}
+	}
+
+	products := make(Products, len(rows))
+	for i, row := range rows {
+		product := row.Product
+		products[i] = &product
+	}
+	return products, next, nil
+}
+
+// sortValue returns the value of the row's sort column used in the cursor
+func (s *searchRow) sortValue(sortKey string) interface{} {
+	switch strings.TrimPrefix(sortKey, "-") {
+	case SortRelevance:
+		return s.Score
+	case "kcal":
+		return s.Kcal.InexactFloat64()
+	case "proteins":
+		return s.Proteins.InexactFloat64()
+	case "carbs":
+		return s.Carbs.InexactFloat64()
+	case "fats":
+		return s.Fats.InexactFloat64()
+	}
+	return s.ProductName
+}
+
 // Create inserts a new product into the database
 func (r *Repository) Create(product *Product) (*Product, error) {
 	// GORM automatically handles CreatedAt and UpdatedAt
@@ -36,6 +178,50 @@ func (r *Repository) Create(product *Product) (*Product, error) {
 	return product, nil
 }
 
+// ImportBatch upserts catalog products keyed by barcode in a single transaction,
+// so repeated imports refresh existing rows instead of duplicating them. Every
+// product must have a normalized barcode, unique within the batch, and an ID
+// (used only for new rows). Products owned by users have their own barcode
+// scope and are never touched. Batches are limited to MaxImportBatch products.
+// Returns how many rows were inserted and updated.
+func (r *Repository) ImportBatch(products Products) (inserted, updated int, err error) {
+	if len(products) == 0 {
+		return 0, 0, nil
+	}
+	if len(products) > MaxImportBatch {
+		return 0, 0, fmt.Errorf("batch of %d products exceeds the limit of %d", len(products), MaxImportBatch)
+	}
+	barcodes := make([]string, len(products))
+	for i, p := range products {
+		if p.Barcode == nil {
+			return 0, 0, fmt.Errorf("product %q has no barcode", p.ProductName)
+		}
+		barcodes[i] = *p.Barcode
+	}
+
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&Product{}).Where("barcode IN ? AND owner_id IS NULL", barcodes).Count(&existing).Error; err != nil {
+			return err
+		}
+		err := tx.Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "barcode"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "barcode IS NOT NULL AND owner_id IS NULL AND deleted_at IS NULL"}}},
+			DoUpdates:   clause.AssignmentColumns([]string{"product_name", "kcal", "proteins", "carbs", "fats", "nutrients", "updated_at"}),
+		}).Create(&products).Error
+		if err != nil {
+			return err
+		}
+		updated = int(existing)
+		inserted = len(products) - updated
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return inserted, updated, nil
+}
+
 // Read retrieves a single product by its ID
 func (r *Repository) Read(id uuid.UUID) (*Product, error) {
 	product := &Product{}
@@ -47,12 +233,46 @@ func (r *Repository) Read(id uuid.UUID) (*Product, error) {
 	return product, nil
 }
 
+// ReadByBarcode retrieves the product with the normalized barcode that the viewer
+// can see, preferring the viewer's own product, then the catalog, then shared ones
+func (r *Repository) ReadByBarcode(barcode string, viewer uuid.UUID) (*Product, error) {
+	product := &Product{}
+	err := r.db.
+		Where("barcode = ? AND (visibility IN ? OR owner_id = ?)", barcode, PublicVisibilities, viewer).
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "CASE WHEN owner_id = ? THEN 0 WHEN owner_id IS NULL THEN 1 ELSE 2 END, products.id ASC",
+			Vars:               []interface{}{viewer},
+			WithoutParentheses: true,
+		}}).
+		Take(&product).Error
+	if err != nil {
+		return nil, err // Can be gorm.ErrRecordNotFound
+	}
+	return product, nil
+}
+
+// ReadByOwnerBarcode retrieves the product with the normalized barcode within
+// one barcode scope: the owner's products, or the catalog when ownerID is nil
+func (r *Repository) ReadByOwnerBarcode(barcode string, ownerID *uuid.UUID) (*Product, error) {
+	query := r.db.Where("barcode = ?", barcode)
+	if ownerID == nil {
+		query = query.Where("owner_id IS NULL")
+	} else {
+		query = query.Where("owner_id = ?", *ownerID)
+	}
+	product := &Product{}
+	if err := query.First(&product).Error; err != nil {
+		return nil, err // Can be gorm.ErrRecordNotFound
+	}
+	return product, nil
+}
+
 // Update modifies an existing product in the database
 func (r *Repository) Update(product *Product) (int64, error) {
 	// GORM automatically handles UpdatedAt
 	// Select specifies which fields are allowed to be updated
 	result := r.db.Model(&Product{}).
-		Select("ProductName", "Kcal", "Proteins", "Carbs", "Fats", "UpdatedAt").
+		Select("ProductName", "Kcal", "Proteins", "Carbs", "Fats", "Density", "Barcode", "Nutrients", "Visibility", "UpdatedAt").
 		Where("id = ?", product.ID).
 		Updates(product) // Pass the product struct with new values
 
@@ -71,6 +291,61 @@ func (r *Repository) Delete(id uuid.UUID) (int64, error) {
 	return result.RowsAffected, result.Error
 }
 
+// ReadDeleted retrieves a soft-deleted product by its ID
+func (r *Repository) ReadDeleted(id uuid.UUID) (*Product, error) {
+	product := &Product{}
+	if err := r.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&product).Error; err != nil {
+		return nil, err // Can be gorm.ErrRecordNotFound
+	}
+	return product, nil
+}
+
+// Restore undoes the soft delete of a product
+func (r *Repository) Restore(id uuid.UUID) (int64, error) {
+	result := r.db.Unscoped().Model(&Product{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	return result.RowsAffected, result.Error
+}
+
+// SetVisibility changes the visibility of a product
+func (r *Repository) SetVisibility(id uuid.UUID, visibility string) (int64, error) {
+	result := r.db.Model(&Product{}).Where("id = ?", id).Update("visibility", visibility)
+	return result.RowsAffected, result.Error
+}
+
+// ListServings retrieves all non-deleted named servings of a product
+func (r *Repository) ListServings(productID uuid.UUID) (Servings, error) {
+	servings := make([]*Serving, 0)
+	if err := r.db.Where("product_id = ?", productID).Order("serving_name").Find(&servings).Error; err != nil {
+		return nil, err
+	}
+	return servings, nil
+}
+
+// CreateServing inserts a new named serving of a product
+func (r *Repository) CreateServing(serving *Serving) (*Serving, error) {
+	if err := r.db.Create(serving).Error; err != nil {
+		return nil, err
+	}
+	return serving, nil
+}
+
+// FindServing retrieves a product's serving by its (normalized) name
+func (r *Repository) FindServing(productID uuid.UUID, name string) (*Serving, error) {
+	serving := &Serving{}
+	if err := r.db.Where("product_id = ? AND serving_name = ?", productID, name).First(&serving).Error; err != nil {
+		return nil, err // Can be gorm.ErrRecordNotFound
+	}
+	return serving, nil
+}
+
+// DeleteServing performs a soft delete on a serving, scoped to its product
+func (r *Repository) DeleteServing(productID uuid.UUID, id uuid.UUID) (int64, error) {
+	result := r.db.Where("id = ? AND product_id = ?", id, productID).Delete(&Serving{})
+	return result.RowsAffected, result.Error
+}
+
 // Helper to explicitly tell GORM the table name if needed (usually inferred)
 // func (Product) TableName() string {
 //  return "products"
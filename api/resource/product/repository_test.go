@@ -7,8 +7,10 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 
+	"fitapp-backend/api/resource/common/nutrient"
 	"fitapp-backend/api/resource/product" // Adjust import path as needed
 	mockDB "fitapp-backend/mock/db"       // Adjust import path as needed
 	testUtil "fitapp-backend/util/test"   // Adjust import path as needed
@@ -54,13 +56,17 @@ func TestRepository_Create(t *testing.T) {
 
 	// Prepare the product data to be created
 	id := uuid.New()
+	ownerID := uuid.New()
 	newProduct := &product.Product{
 		ID:          id, // ID is set before calling Create in the handler
 		ProductName: "New Product",
-		Kcal:        150,
-		Proteins:    15,
-		Carbs:       10,
-		Fats:        5,
+		Kcal:        decimal.NewFromInt(150),
+		Proteins:    decimal.NewFromInt(15),
+		Carbs:       decimal.NewFromInt(10),
+		Fats:        decimal.NewFromInt(5),
+		Nutrients:   nutrient.Set{nutrient.Fiber: decimal.RequireFromString("2.5")},
+		OwnerID:     &ownerID,
+		Visibility:  product.VisibilityPrivate,
 		// CreatedAt, UpdatedAt, DeletedAt are handled by GORM/DB
 	}
 
@@ -69,7 +75,7 @@ func TestRepository_Create(t *testing.T) {
 	// Expect an INSERT statement
 	// The exact columns and placeholders depend on GORM version and configuration.
 	// This regex assumes GORM inserts all non-zero fields + auto fields.
-	expectedSQL := regexp.QuoteMeta(`INSERT INTO "products" ("id","created_at","updated_at","deleted_at","product_name","kcal","proteins","carbs","fats") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)`) // Adjust based on actual query
+	expectedSQL := regexp.QuoteMeta(`INSERT INTO "products" ("id","created_at","updated_at","deleted_at","product_name","kcal","proteins","carbs","fats","density","barcode","nutrients","owner_id","visibility") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14)`) // Adjust based on actual query
 	mock.ExpectExec(expectedSQL).
 		WithArgs(
 			newProduct.ID,
@@ -81,6 +87,11 @@ func TestRepository_Create(t *testing.T) {
 			newProduct.Proteins,
 			newProduct.Carbs,
 			newProduct.Fats,
+			nil, // Density is optional
+			nil, // Barcode is optional
+			`{"fiber":"2.5"}`,
+			ownerID,
+			product.VisibilityPrivate,
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1)) // Simulate 1 row inserted
 	// Expect transaction commit
@@ -111,10 +122,10 @@ func TestRepository_Read(t *testing.T) {
 		AddRow(id, time.Now(), time.Now(), gorm.DeletedAt{}, expectedProductName, 200, 20, 25, 10)
 
 	// Expect a SELECT query with a WHERE clause for the ID
-	// GORM's First usually generates `SELECT * FROM "products" WHERE id = $1 AND "products"."deleted_at" IS NULL ORDER BY "products"."id" LIMIT 1`
-	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "products" WHERE id = $1 AND "products"."deleted_at" IS NULL ORDER BY "products"."id" LIMIT 1`)
+	// GORM's First usually generates `SELECT * FROM "products" WHERE id = $1 AND "products"."deleted_at" IS NULL ORDER BY "products"."id" LIMIT $2`
+	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "products" WHERE id = $1 AND "products"."deleted_at" IS NULL ORDER BY "products"."id" LIMIT $2`)
 	mock.ExpectQuery(expectedSQL).
-		WithArgs(id).            // Expect the ID as argument
+		WithArgs(id, 1).         // Expect the ID and LIMIT as arguments
 		WillReturnRows(mockRows) // Return the mock row
 
 	foundProduct, err := repo.Read(id)
@@ -137,9 +148,9 @@ func TestRepository_Read_NotFound(t *testing.T) {
 	id := uuid.New()
 
 	// Expect the query but return gorm.ErrRecordNotFound
-	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "products" WHERE id = $1 AND "products"."deleted_at" IS NULL ORDER BY "products"."id" LIMIT 1`)
+	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "products" WHERE id = $1 AND "products"."deleted_at" IS NULL ORDER BY "products"."id" LIMIT $2`)
 	mock.ExpectQuery(expectedSQL).
-		WithArgs(id).
+		WithArgs(id, 1).
 		WillReturnError(gorm.ErrRecordNotFound) // Simulate record not found
 
 	_, err = repo.Read(id)
@@ -149,6 +160,48 @@ func TestRepository_Read_NotFound(t *testing.T) {
 	testUtil.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestRepository_ReadByBarcode(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	repo := product.NewRepository(db)
+	id, viewer, barcode := uuid.New(), uuid.New(), "5901234123457"
+
+	// The viewer's own product comes first, then the catalog, then products shared by others
+	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "products" WHERE (barcode = $1 AND (visibility IN ($2,$3) OR owner_id = $4)) AND "products"."deleted_at" IS NULL ORDER BY CASE WHEN owner_id = $5 THEN 0 WHEN owner_id IS NULL THEN 1 ELSE 2 END, products.id ASC LIMIT $6`)
+	mock.ExpectQuery(expectedSQL).
+		WithArgs(barcode, product.VisibilityShared, product.VisibilityGlobal, viewer, viewer, 1).
+		WillReturnRows(sqlmock.NewRows(productColumns).
+			AddRow(id, time.Now(), time.Now(), gorm.DeletedAt{}, "Jogurt", 61, 4, 5, 2))
+
+	found, err := repo.ReadByBarcode(barcode, viewer)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, id, found.ID)
+
+	// Barcode conflicts are checked within one owner's products only
+	expectedSQL = regexp.QuoteMeta(`SELECT * FROM "products" WHERE barcode = $1 AND owner_id = $2 AND "products"."deleted_at" IS NULL ORDER BY "products"."id" LIMIT $3`)
+	mock.ExpectQuery(expectedSQL).
+		WithArgs(barcode, viewer, 1).
+		WillReturnRows(sqlmock.NewRows(productColumns))
+
+	_, err = repo.ReadByOwnerBarcode(barcode, &viewer)
+	testUtil.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// ...or within the catalog for products without an owner
+	expectedSQL = regexp.QuoteMeta(`SELECT * FROM "products" WHERE barcode = $1 AND owner_id IS NULL AND "products"."deleted_at" IS NULL ORDER BY "products"."id" LIMIT $2`)
+	mock.ExpectQuery(expectedSQL).
+		WithArgs(barcode, 1).
+		WillReturnRows(sqlmock.NewRows(productColumns))
+
+	_, err = repo.ReadByOwnerBarcode(barcode, nil)
+	testUtil.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// Ensure all expectations were met
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestRepository_Update(t *testing.T) {
 	t.Parallel()
 
@@ -161,10 +214,11 @@ func TestRepository_Update(t *testing.T) {
 	productToUpdate := &product.Product{
 		ID:          id,
 		ProductName: "Updated Product",
-		Kcal:        300,
-		Proteins:    30,
-		Carbs:       35,
-		Fats:        15,
+		Kcal:        decimal.NewFromInt(300),
+		Proteins:    decimal.NewFromInt(30),
+		Carbs:       decimal.NewFromInt(35),
+		Fats:        decimal.NewFromInt(15),
+		Visibility:  product.VisibilityShared,
 		// UpdatedAt is handled by GORM
 	}
 
@@ -172,16 +226,20 @@ func TestRepository_Update(t *testing.T) {
 	mock.ExpectBegin()
 	// Expect an UPDATE statement
 	// GORM's Updates with Select generates specific SET clauses
-	expectedSQL := regexp.QuoteMeta(`UPDATE "products" SET "product_name"=$1,"kcal"=$2,"proteins"=$3,"carbs"=$4,"fats"=$5,"updated_at"=$6 WHERE id = $7`)
+	expectedSQL := regexp.QuoteMeta(`UPDATE "products" SET "updated_at"=$1,"product_name"=$2,"kcal"=$3,"proteins"=$4,"carbs"=$5,"fats"=$6,"density"=$7,"barcode"=$8,"nutrients"=$9,"visibility"=$10 WHERE id = $11 AND "products"."deleted_at" IS NULL`)
 	mock.ExpectExec(expectedSQL).
 		WithArgs(
+			mockDB.AnyTime{}, // UpdatedAt
 			productToUpdate.ProductName,
 			productToUpdate.Kcal,
 			productToUpdate.Proteins,
 			productToUpdate.Carbs,
 			productToUpdate.Fats,
-			mockDB.AnyTime{}, // UpdatedAt
-			id,               // WHERE clause ID
+			nil,  // Density
+			nil,  // Barcode
+			"{}", // Nutrients
+			product.VisibilityShared,
+			id, // WHERE clause ID
 		).
 		WillReturnResult(sqlmock.NewResult(0, 1)) // Simulate 1 row affected
 	// Expect transaction commit
@@ -223,3 +281,170 @@ func TestRepository_Delete(t *testing.T) {
 	// Ensure all expectations were met
 	testUtil.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestRepository_FindServing(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	repo := product.NewRepository(db)
+
+	productID := uuid.New()
+	servingID := uuid.New()
+
+	mockRows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "deleted_at", "product_id", "serving_name", "amount", "unit"}).
+		AddRow(servingID, time.Now(), time.Now(), gorm.DeletedAt{}, productID, "cup", 240.0, product.UnitMilliliters)
+
+	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "product_servings" WHERE (product_id = $1 AND serving_name = $2) AND "product_servings"."deleted_at" IS NULL ORDER BY "product_servings"."id" LIMIT $3`)
+	mock.ExpectQuery(expectedSQL).
+		WithArgs(productID, "cup", 1).
+		WillReturnRows(mockRows)
+
+	serving, err := repo.FindServing(productID, "cup")
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, servingID, serving.ID)
+
+	// A volume serving is converted to grams with the product's density
+	density := 1.5
+	grams, err := (&product.Product{Density: &density}).ToGrams(serving.Amount, serving.Unit)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 360.0, grams)
+
+	_, err = (&product.Product{}).ToGrams(serving.Amount, serving.Unit)
+	testUtil.ErrorIs(t, err, product.ErrDensityRequired)
+
+	// Ensure all expectations were met
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNormalizeBarcode(t *testing.T) {
+	t.Parallel()
+
+	// UPC-A and its EAN-13 form normalize to the same code
+	upc, err := product.NormalizeBarcode("036000291452")
+	testUtil.NoError(t, err)
+	ean, err := product.NormalizeBarcode("0036000291452")
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, "0036000291452", upc)
+	testUtil.Equal(t, upc, ean)
+
+	// EAN-8 is zero-padded to 13 digits
+	ean8, err := product.NormalizeBarcode("9638-5074")
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, "0000096385074", ean8)
+
+	// EAN-13 with a regular prefix
+	ean13, err := product.NormalizeBarcode("5901234123457")
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, "5901234123457", ean13)
+
+	_, err = product.NormalizeBarcode("5901234123458") // Wrong check digit
+	testUtil.ErrorIs(t, err, product.ErrInvalidBarcode)
+	_, err = product.NormalizeBarcode("12345") // Wrong length
+	testUtil.ErrorIs(t, err, product.ErrInvalidBarcode)
+	_, err = product.NormalizeBarcode("59012341234AB")
+	testUtil.ErrorIs(t, err, product.ErrInvalidBarcode)
+}
+
+func TestRepository_Search(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	repo := product.NewRepository(db)
+
+	lastID := uuid.New()
+	viewer := uuid.New()
+	maxKcal := decimal.RequireFromString("400.5")
+	cursor := &product.Cursor{Value: 0.5, ID: lastID}
+
+	mockRows := sqlmock.NewRows(append(append([]string{}, productColumns...), "score")).
+		AddRow(uuid.New(), time.Now(), time.Now(), gorm.DeletedAt{}, "Jogurt naturalny", 60, 4, 5, 3, 0.45).
+		AddRow(uuid.New(), time.Now(), time.Now(), gorm.DeletedAt{}, "Jogurt grecki", 120, 9, 4, 10, 0.4)
+
+	expectedSQL := regexp.QuoteMeta(`SELECT products.*, similarity(f_unaccent(lower(product_name)), f_unaccent(lower($1))) AS score FROM "products" WHERE (visibility IN ($2,$3) OR owner_id = $4) AND (f_unaccent(lower(product_name)) % f_unaccent(lower($5)) OR strpos(f_unaccent(lower(product_name)), f_unaccent(lower($6))) > 0) AND kcal <= $7 AND ((similarity(f_unaccent(lower(product_name)), f_unaccent(lower($8))) < $9) OR (similarity(f_unaccent(lower(product_name)), f_unaccent(lower($10))) = $11 AND products.id > $12)) AND "products"."deleted_at" IS NULL ORDER BY similarity(f_unaccent(lower(product_name)), f_unaccent(lower($13))) DESC, products.id ASC LIMIT $14`)
+	mock.ExpectQuery(expectedSQL).
+		WithArgs("jogurt", product.VisibilityShared, product.VisibilityGlobal, viewer, "jogurt", "jogurt", maxKcal, "jogurt", 0.5, "jogurt", 0.5, lastID, "jogurt", 2).
+		WillReturnRows(mockRows)
+
+	products, next, err := repo.Search(&product.SearchParams{
+		Query:  "jogurt",
+		Ranges: map[string]product.Range{"kcal": {Max: &maxKcal}},
+		Limit:  1,
+		Cursor: cursor,
+		Viewer: viewer,
+	})
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 1, len(products))
+	testUtil.Equal(t, "Jogurt naturalny", products[0].ProductName)
+	testUtil.NotNil(t, next)
+	testUtil.Equal(t, products[0].ID, next.ID)
+	testUtil.Equal(t, interface{}(0.45), next.Value)
+
+	// Cursors survive the round trip through the opaque token
+	decoded, err := product.DecodeCursor(next.Encode())
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, next.ID, decoded.ID)
+
+	// ...but only for the sort and query they were issued for
+	testUtil.Equal(t, true, decoded.Matches(&product.SearchParams{Query: "jogurt"}))
+	testUtil.Equal(t, false, decoded.Matches(&product.SearchParams{Query: "jogurt", Sort: "name"}))
+	testUtil.Equal(t, false, decoded.Matches(&product.SearchParams{Query: "jogurt", Sort: "kcal"}))
+	testUtil.Equal(t, false, decoded.Matches(&product.SearchParams{Query: "kefir"}))
+	forged := &product.Cursor{Value: "Jogurt", ID: lastID, Sort: "kcal"}
+	testUtil.Equal(t, false, forged.Matches(&product.SearchParams{Sort: "kcal"}))
+
+	// Ensure all expectations were met
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_ImportBatch(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	repo := product.NewRepository(db)
+
+	existing, fresh := "5901234123457", "0000096385074"
+	batch := product.Products{
+		{ID: uuid.New(), ProductName: "Jogurt naturalny", Kcal: decimal.NewFromInt(61), Barcode: &existing, Visibility: product.VisibilityGlobal},
+		{ID: uuid.New(), ProductName: "Baton", Kcal: decimal.NewFromInt(500), Barcode: &fresh, Visibility: product.VisibilityGlobal},
+	}
+
+	mock.ExpectBegin()
+	// Only catalog rows count; a user's own product with the same barcode is in a separate scope
+	expectedSQL := regexp.QuoteMeta(`SELECT count(*) FROM "products" WHERE (barcode IN ($1,$2) AND owner_id IS NULL) AND "products"."deleted_at" IS NULL`)
+	mock.ExpectQuery(expectedSQL).
+		WithArgs(existing, fresh).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	expectedSQL = regexp.QuoteMeta(`INSERT INTO "products" ("id","created_at","updated_at","deleted_at","product_name","kcal","proteins","carbs","fats","density","barcode","nutrients","owner_id","visibility") VALUES `) +
+		`.+` + regexp.QuoteMeta(` ON CONFLICT ("barcode") WHERE barcode IS NOT NULL AND owner_id IS NULL AND deleted_at IS NULL DO UPDATE SET "product_name"="excluded"."product_name","kcal"="excluded"."kcal","proteins"="excluded"."proteins","carbs"="excluded"."carbs","fats"="excluded"."fats","nutrients"="excluded"."nutrients","updated_at"="excluded"."updated_at"`) + "$"
+	mock.ExpectExec(expectedSQL).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	inserted, updated, err := repo.ImportBatch(batch)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 1, inserted)
+	testUtil.Equal(t, 1, updated)
+
+	// Products without a barcode cannot be de-duplicated and are rejected
+	_, _, err = repo.ImportBatch(product.Products{{ProductName: "No barcode"}})
+	if err == nil {
+		t.Fatal("expected error for product without barcode")
+	}
+
+	// A batch binds 14 parameters per product, PostgreSQL allows 65535 per statement
+	testUtil.Equal(t, 4681, product.MaxImportBatch)
+	oversized := make(product.Products, product.MaxImportBatch+1)
+	_, _, err = repo.ImportBatch(oversized)
+	if err == nil {
+		t.Fatal("expected error for a batch over MaxImportBatch")
+	}
+
+	// Ensure all expectations were met
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
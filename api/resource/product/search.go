@@ -0,0 +1,124 @@
+package product
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Search defaults and limits
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+	SortRelevance      = "relevance"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded or
+// was issued for a different sort or query
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// sortColumns maps the public sort keys to product columns
+var sortColumns = map[string]string{
+	"name":     "product_name",
+	"kcal":     "kcal",
+	"proteins": "proteins",
+	"carbs":    "carbs",
+	"fats":     "fats",
+}
+
+// NutrientColumns lists the columns that can be filtered by range
+var NutrientColumns = []string{"kcal", "proteins", "carbs", "fats"}
+
+// Range is an inclusive filter on a per-100g nutrient value; nil bounds are open
+type Range struct {
+	Min *decimal.Decimal
+	Max *decimal.Decimal
+}
+
+// SearchParams describes a product search query
+type SearchParams struct {
+	Query  string           // Fuzzy, case and diacritic insensitive name match
+	Ranges map[string]Range // Keyed by nutrient column, see NutrientColumns
+	Sort   string           // "relevance" or a sort key, "-" prefix for descending
+	Limit  int
+	Cursor *Cursor
+	Viewer uuid.UUID // The viewer's private products are merged with the public catalog
+	Mine   bool      // Only products owned by Viewer
+}
+
+// Cursor marks the last product of a page for keyset pagination
+type Cursor struct {
+	Value interface{} `json:"v"` // Sort value of the last product
+	ID    uuid.UUID   `json:"id"`
+	Sort  string      `json:"s"` // Resolved sort key the cursor was issued for
+	Query string      `json:"q"` // Name search the cursor was issued for
+}
+
+// ListDTO represents a page of products
+type ListDTO struct {
+	Products   []*DTO `json:"products"`
+	NextCursor string `json:"next_cursor,omitempty"` // Empty on the last page
+}
+
+// ValidSort reports whether sort is a supported sort option
+func ValidSort(sort string) bool {
+	if sort == SortRelevance {
+		return true
+	}
+	if len(sort) > 0 && sort[0] == '-' {
+		sort = sort[1:]
+	}
+	_, ok := sortColumns[sort]
+	return ok
+}
+
+// SortKey returns the sort in effect: the requested one, else relevance when
+// searching by name and the name otherwise
+func (p *SearchParams) SortKey() string {
+	if p.Sort != "" {
+		return p.Sort
+	}
+	if p.Query != "" {
+		return SortRelevance
+	}
+	return "name"
+}
+
+// Matches reports whether the cursor was issued for the sort and query of params,
+// so that its value compares with the right column
+func (c *Cursor) Matches(params *SearchParams) bool {
+	sortKey := params.SortKey()
+	if c.Sort != sortKey || c.Query != params.Query {
+		return false
+	}
+	switch c.Value.(type) {
+	case string:
+		return strings.TrimPrefix(sortKey, "-") == "name"
+	case float64:
+		return strings.TrimPrefix(sortKey, "-") != "name"
+	}
+	return false
+}
+
+// Encode serializes the cursor into an opaque URL-safe token
+func (c *Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a token produced by Cursor.Encode
+func DecodeCursor(token string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	cursor := &Cursor{}
+	if err := json.Unmarshal(raw, cursor); err != nil || cursor.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+	return cursor, nil
+}
@@ -1,14 +1,33 @@
 package product
 
+import (
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"fitapp-backend/api/resource/common/caller"
+	"fitapp-backend/api/resource/common/nutrient"
+)
+
+// ErrDensityRequired is returned when a volume amount is used for a product without density
+var ErrDensityRequired = errors.New("product density is required for volume units")
+
 // ToDto converts a Product model to its DTO representation
 func (p *Product) ToDto() *DTO {
 	return &DTO{
 		ID:          p.ID.String(),
 		ProductName: p.ProductName,
-		Kcal:        p.Kcal,
-		Proteins:    p.Proteins,
-		Carbs:       p.Carbs,
-		Fats:        p.Fats,
+		Kcal:        nutrient.Kcal(p.Kcal),
+		Proteins:    nutrient.Macro(p.Proteins),
+		Carbs:       nutrient.Macro(p.Carbs),
+		Fats:        nutrient.Macro(p.Fats),
+		Density:     p.Density,
+		Barcode:     p.Barcode,
+		Nutrients:   p.Nutrients.ToDto(),
+		OwnerID:     ownerString(p.OwnerID),
+		Visibility:  p.Visibility,
 	}
 }
 
@@ -21,13 +40,101 @@ func (ps Products) ToDto() []*DTO {
 	return dtos
 }
 
+// ownerString formats an optional owner ID, returning an empty string for nil
+func ownerString(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+// ValidVisibility reports whether v is a known visibility level
+func ValidVisibility(v string) bool {
+	return v == VisibilityPrivate || v == VisibilityShared || v == VisibilityGlobal
+}
+
+// VisibleTo reports whether the product is visible to the given user
+func (p *Product) VisibleTo(userID uuid.UUID) bool {
+	return p.Visibility != VisibilityPrivate || p.OwnedBy(userID)
+}
+
+// OwnedBy reports whether the given user owns the product
+func (p *Product) OwnedBy(userID uuid.UUID) bool {
+	return p.OwnerID != nil && *p.OwnerID == userID
+}
+
+// ModifiableBy reports whether the caller may update or delete the product:
+// admins may modify any product, users only their own non-global products
+func (p *Product) ModifiableBy(c *caller.Caller) bool {
+	if c.Admin {
+		return true
+	}
+	return p.Visibility != VisibilityGlobal && p.OwnedBy(c.UserID)
+}
+
+// BaseGrams returns the amount the form's nutritional values refer to, in grams.
+// Returns 0 if neither grams nor millilitres with a density are given.
+func (f *Form) BaseGrams() float64 {
+	if f.Grams > 0 {
+		return float64(f.Grams)
+	}
+	if f.Milliliters > 0 && f.Density != nil && *f.Density > 0 {
+		return float64(f.Milliliters) * *f.Density
+	}
+	return 0
+}
+
 // ToModel converts a Form to a Product model (ID needs to be set separately)
 func (f *Form) ToModel() *Product {
+	grams := decimal.NewFromFloat(f.BaseGrams())
 	return &Product{
 		ProductName: f.ProductName,
-		Kcal:        int(float64(f.Kcal) * 100 / float64(f.Grams)),
-		Proteins:    int(float64(f.Proteins) * 100 / float64(f.Grams)),
-		Carbs:       int(float64(f.Carbs) * 100 / float64(f.Grams)),
-		Fats:        int(float64(f.Fats) * 100 / float64(f.Grams)),
+		Kcal:        nutrient.Per100g(f.Kcal, grams),
+		Proteins:    nutrient.Per100g(f.Proteins, grams),
+		Carbs:       nutrient.Per100g(f.Carbs, grams),
+		Fats:        nutrient.Per100g(f.Fats, grams),
+		Density:     f.Density,
+		Nutrients:   f.Nutrients.Per100g(grams).Complete(),
+		Visibility:  f.Visibility,
+	}
+}
+
+// ToGrams converts an amount in the given unit to grams using the product's density
+func (p *Product) ToGrams(amount float64, unit string) (float64, error) {
+	switch unit {
+	case UnitGrams:
+		return amount, nil
+	case UnitMilliliters:
+		if p.Density == nil || *p.Density <= 0 {
+			return 0, ErrDensityRequired
+		}
+		return amount * *p.Density, nil
+	}
+	return 0, errors.New("unknown unit: " + unit)
+}
+
+// NormalizeServingName trims and lowercases a serving name so lookups are consistent
+func NormalizeServingName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// ToDto converts a Serving to its DTO representation, resolving grams via the product
+func (s *Serving) ToDto(p *Product) *ServingDTO {
+	grams, _ := p.ToGrams(s.Amount, s.Unit) // Validated on creation; 0 if density was removed since
+	return &ServingDTO{
+		ID:          s.ID.String(),
+		ServingName: s.ServingName,
+		Amount:      s.Amount,
+		Unit:        s.Unit,
+		Grams:       grams,
 	}
 }
+
+// ToDto converts a slice of Servings of the given product to a slice of DTOs
+func (ss Servings) ToDto(p *Product) []*ServingDTO {
+	dtos := make([]*ServingDTO, len(ss))
+	for i, s := range ss {
+		dtos[i] = s.ToDto(p)
+	}
+	return dtos
+}
@@ -0,0 +1,402 @@
+package recipe
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"fitapp-backend/api/resource/common/caller"
+	"fitapp-backend/api/resource/product"
+)
+
+// API holds the dependencies for the recipe handlers
+type API struct {
+	repository        *Repository
+	productRepository *product.Repository
+}
+
+// New creates a new API instance for recipe routes
+func New(db *gorm.DB) *API {
+	return &API{
+		repository:        NewRepository(db),
+		productRepository: product.NewRepository(db),
+	}
+}
+
+func handleErr(w http.ResponseWriter, status int, message string, err error) {
+	// Log the error internally
+	fmt.Printf("ERROR [%d]: %s - %v\n", status, message, err)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+func enableCors(w *http.ResponseWriter) {
+	(*w).Header().Set("Access-Control-Allow-Origin", "*")
+}
+
+// toModel validates the form and builds a recipe with its ingredients.
+// Returns nil if an error response has already been written.
+func (a *API) toModel(w http.ResponseWriter, r *http.Request, form *Form) *Recipe {
+	userID, err := uuid.Parse(form.UserID)
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid user_id format (must be UUID)", err)
+		return nil
+	}
+	if !caller.CanAccess(r, userID) {
+		handleErr(w, http.StatusNotFound, "User not found", nil)
+		return nil
+	}
+
+	if form.RecipeName == "" {
+		handleErr(w, http.StatusUnprocessableEntity, "recipe_name is required", nil)
+		return nil
+	}
+	if form.Servings <= 0 {
+		handleErr(w, http.StatusUnprocessableEntity, "servings must be greater than zero", nil)
+		return nil
+	}
+	if form.CookedWeight != nil && *form.CookedWeight <= 0 {
+		handleErr(w, http.StatusUnprocessableEntity, "cooked_weight must be greater than zero", nil)
+		return nil
+	}
+	if len(form.Ingredients) == 0 {
+		handleErr(w, http.StatusUnprocessableEntity, "At least one ingredient is required", nil)
+		return nil
+	}
+
+	ingredients := make([]Ingredient, len(form.Ingredients))
+	for i, ing := range form.Ingredients {
+		productID, err := uuid.Parse(ing.ProductID)
+		if err != nil {
+			handleErr(w, http.StatusBadRequest, "Invalid ingredient product_id format (must be UUID)", err)
+			return nil
+		}
+		if ing.Grams <= 0 {
+			handleErr(w, http.StatusUnprocessableEntity, "Ingredient grams must be greater than zero", nil)
+			return nil
+		}
+		p, err := a.productRepository.Read(productID)
+		if err == nil && !p.VisibleTo(userID) {
+			err = gorm.ErrRecordNotFound // Other users' private products cannot be used
+		}
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				handleErr(w, http.StatusUnprocessableEntity, fmt.Sprintf("Product %s not found", productID), err)
+			} else {
+				handleErr(w, http.StatusInternalServerError, "Failed to read product", err)
+			}
+			return nil
+		}
+		ingredients[i] = Ingredient{
+			ID:        uuid.New(),
+			ProductID: productID,
+			Grams:     ing.Grams,
+		}
+	}
+
+	return &Recipe{
+		UserID:       userID,
+		RecipeName:   form.RecipeName,
+		Servings:     form.Servings,
+		CookedWeight: form.CookedWeight,
+		Ingredients:  ingredients,
+	}
+}
+
+// readOwned loads the recipe of the id path parameter if the caller may access
+// it; other users' recipes are reported as not found.
+// Returns nil if an error response has been written.
+func (a *API) readOwned(w http.ResponseWriter, r *http.Request) *Recipe {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid ID format (must be UUID)", err)
+		return nil
+	}
+
+	recipe, err := a.repository.Read(id)
+	if err == nil && !caller.CanAccess(r, recipe.UserID) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			handleErr(w, http.StatusNotFound, "Recipe not found", err)
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to read recipe", err)
+		}
+		return nil
+	}
+	return recipe
+}
+
+// List godoc
+//
+//	@summary		List recipes
+//	@description	List recipes with computed nutrition. Callers see their own recipes,
+//	@description	admins see all recipes or those of the given user.
+//	@tags			recipes
+//	@accept			json
+//	@produce		json
+//	@param			userId	query		string	false	"User ID (UUID), defaults to the caller unless admin" format(uuid)
+//	@success		200	{array}		DTO
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/recipes [get]
+func (a *API) List(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	c, ok := caller.FromRequest(r)
+	if !ok {
+		handleErr(w, http.StatusUnauthorized, "Caller not identified", nil)
+		return
+	}
+	userID := uuid.Nil // All recipes
+	if !c.Admin {
+		userID = c.UserID
+	}
+	if userIdParam := r.URL.Query().Get("userId"); userIdParam != "" {
+		var err error
+		userID, err = uuid.Parse(userIdParam)
+		if err != nil {
+			handleErr(w, http.StatusBadRequest, "Invalid userId format (must be UUID)", err)
+			return
+		}
+		if !c.CanAccess(userID) {
+			handleErr(w, http.StatusNotFound, "User not found", nil)
+			return
+		}
+	}
+
+	recipes, err := a.repository.List(userID)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to retrieve recipes", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if len(recipes) == 0 {
+		fmt.Fprint(w, "[]")
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(recipes.ToDto()); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode response", err)
+		return
+	}
+}
+
+// Create godoc
+//
+//	@summary		Create recipe
+//	@description	Create a recipe composed of products
+//	@tags			recipes
+//	@accept			json
+//	@produce		json
+//	@param			body	body	Form	true	"Recipe form"
+//	@success		201	{object}	DTO "Returns the created recipe with computed nutrition"
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		422	{object}	map[string]string "Unprocessable Entity"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/recipes [post]
+func (a *API) Create(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	form := &Form{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid request body JSON", err)
+		return
+	}
+
+	newRecipe := a.toModel(w, r, form)
+	if newRecipe == nil {
+		return
+	}
+	newRecipe.ID = uuid.New()
+
+	if _, err := a.repository.Create(newRecipe); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to create recipe", err)
+		return
+	}
+
+	// Re-read to load the ingredient products for the nutrition calculation
+	createdRecipe, err := a.repository.Read(newRecipe.ID)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to read recipe after create", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(createdRecipe.ToDto()); err != nil {
+		fmt.Printf("Error encoding created recipe response: %v\n", err)
+	}
+}
+
+// Read godoc
+//
+//	@summary		Read recipe
+//	@description	Read a single recipe with per-100g and per-serving nutrition
+//	@tags			recipes
+//	@accept			json
+//	@produce		json
+//	@param			id	path		string	true	"Recipe ID (UUID)"
+//	@success		200	{object}	DTO
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/recipes/{id} [get]
+func (a *API) Read(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	recipe := a.readOwned(w, r)
+	if recipe == nil {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(recipe.ToDto()); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode response", err)
+		return
+	}
+}
+
+// Update godoc
+//
+//	@summary		Update recipe
+//	@description	Update a recipe and replace its ingredient list
+//	@tags			recipes
+//	@accept			json
+//	@produce		json
+//	@param			id		path	string	true	"Recipe ID (UUID)"
+//	@param			body	body	Form	true	"Recipe form"
+//	@success		200	{object}	DTO "Returns the updated recipe"
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		422	{object}	map[string]string "Unprocessable Entity"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/recipes/{id} [put]
+func (a *API) Update(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	existing := a.readOwned(w, r)
+	if existing == nil {
+		return
+	}
+	id := existing.ID
+
+	form := &Form{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid request body JSON", err)
+		return
+	}
+
+	recipe := a.toModel(w, r, form)
+	if recipe == nil {
+		return
+	}
+	recipe.ID = id
+
+	rowsAffected, err := a.repository.Update(recipe)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to update recipe", err)
+		return
+	}
+
+	if rowsAffected == 0 {
+		handleErr(w, http.StatusNotFound, "Recipe not found", nil)
+		return
+	}
+
+	updatedRecipe, err := a.repository.Read(id)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to read recipe after update", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(updatedRecipe.ToDto())
+}
+
+// Delete godoc
+//
+//	@summary		Delete recipe
+//	@description	Soft delete a recipe by ID; already logged entries keep their nutrition
+//	@tags			recipes
+//	@accept			json
+//	@produce		json
+//	@param			id	path	string	true	"Recipe ID (UUID)"
+//	@success		200	{object}	map[string]string "Successfully deleted"
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/recipes/{id} [delete]
+func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	recipe := a.readOwned(w, r)
+	if recipe == nil {
+		return
+	}
+
+	rowsAffected, err := a.repository.Delete(recipe.ID)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to delete recipe", err)
+		return
+	}
+
+	if rowsAffected == 0 {
+		handleErr(w, http.StatusNotFound, "Recipe not found or already deleted", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Recipe deleted successfully"})
+}
+
+// Restore godoc
+//
+//	@summary		Restore recipe (admin)
+//	@description	Undo the soft delete of a recipe
+//	@tags			admin
+//	@accept			json
+//	@produce		json
+//	@security		BearerAuth
+//	@param			id	path		string	true	"Recipe ID (UUID)"
+//	@success		200	{object}	DTO "Returns the restored recipe"
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		403	{object}	map[string]string "Forbidden"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/admin/recipes/{id}/restore [post]
+func (a *API) Restore(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid ID format (must be UUID)", err)
+		return
+	}
+
+	rowsAffected, err := a.repository.Restore(id)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to restore recipe", err)
+		return
+	}
+	if rowsAffected == 0 {
+		handleErr(w, http.StatusNotFound, "Deleted recipe not found", nil)
+		return
+	}
+
+	recipe, err := a.repository.Read(id)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to read restored recipe", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(recipe.ToDto()); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode response", err)
+		return
+	}
+}
@@ -0,0 +1,100 @@
+package recipe
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"fitapp-backend/api/resource/common/nutrient"
+	"fitapp-backend/api/resource/product"
+)
+
+// Recipe represents the structure of the 'recipes' table
+type Recipe struct {
+	ID        uuid.UUID `gorm:"type:uuid;primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	UserID       uuid.UUID    `gorm:"type:uuid;not null"`
+	RecipeName   string       `gorm:"not null"`
+	Servings     int          `gorm:"not null"`
+	CookedWeight *int         // in grams, nil = sum of raw ingredient weights
+	Ingredients  []Ingredient `gorm:"foreignKey:RecipeID"`
+}
+
+// Ingredient represents a product with its amount in the 'recipe_ingredients' table
+type Ingredient struct {
+	ID        uuid.UUID `gorm:"type:uuid;primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	RecipeID  uuid.UUID       `gorm:"type:uuid;not null"`
+	ProductID uuid.UUID       `gorm:"type:uuid;not null"`
+	Grams     int             `gorm:"not null"`
+	Product   product.Product `gorm:"foreignKey:ProductID"`
+}
+
+// TableName overrides the inferred 'ingredients' table name
+func (Ingredient) TableName() string {
+	return "recipe_ingredients"
+}
+
+// Recipes is a slice of Recipe pointers
+type Recipes []*Recipe
+
+// Nutrition holds macro values for a given amount of a recipe
+type Nutrition struct {
+	Kcal      decimal.Decimal
+	Proteins  decimal.Decimal
+	Carbs     decimal.Decimal
+	Fats      decimal.Decimal
+	Nutrients nutrient.Set
+}
+
+// NutritionDTO represents Nutrition rounded for the API
+type NutritionDTO struct {
+	Kcal      float64            `json:"kcal"`
+	Proteins  float64            `json:"proteins"`
+	Carbs     float64            `json:"carbs"`
+	Fats      float64            `json:"fats"`
+	Nutrients map[string]float64 `json:"nutrients,omitempty"`
+}
+
+// DTO represents the data transfer object for a Recipe
+type DTO struct {
+	ID           string           `json:"id"`
+	UserID       string           `json:"user_id"`
+	RecipeName   string           `json:"recipe_name"`
+	Servings     int              `json:"servings"`
+	CookedWeight *int             `json:"cooked_weight,omitempty"`
+	TotalWeight  int              `json:"total_weight"` // grams of the finished dish
+	Ingredients  []*IngredientDTO `json:"ingredients"`
+	Per100g      *NutritionDTO    `json:"per_100g"`
+	PerServing   *NutritionDTO    `json:"per_serving"`
+}
+
+// IngredientDTO represents a single recipe ingredient
+type IngredientDTO struct {
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Grams       int    `json:"grams"`
+}
+
+// Form represents the data structure for creating/updating a Recipe
+type Form struct {
+	UserID       string            `json:"user_id"`
+	RecipeName   string            `json:"recipe_name"`
+	Servings     int               `json:"servings"`
+	CookedWeight *int              `json:"cooked_weight"` // Optional, grams after cooking
+	Ingredients  []*IngredientForm `json:"ingredients"`
+}
+
+// IngredientForm represents a single ingredient in the recipe form
+type IngredientForm struct {
+	ProductID string `json:"product_id"`
+	Grams     int    `json:"grams"`
+}
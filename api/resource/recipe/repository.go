@@ -0,0 +1,102 @@
+package recipe
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Repository handles database operations for recipes and their ingredients
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new recipe repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// preloadProducts loads the ingredients with their products. Soft-deleted
+// products are included, as they still make up existing recipes and their
+// nutrition must not silently drop to zero.
+func preloadProducts(db *gorm.DB) *gorm.DB {
+	return db.Preload("Ingredients.Product", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	})
+}
+
+// List retrieves all non-deleted recipes with their ingredients and products.
+// If userID is not uuid.Nil, only that user's recipes are returned.
+func (r *Repository) List(userID uuid.UUID) (Recipes, error) {
+	recipes := make([]*Recipe, 0)
+	query := preloadProducts(r.db)
+	if userID != uuid.Nil {
+		query = query.Where("user_id = ?", userID)
+	}
+	if err := query.Order("recipe_name").Find(&recipes).Error; err != nil {
+		return nil, err
+	}
+	return recipes, nil
+}
+
+// Create inserts a new recipe together with its ingredients in one transaction
+func (r *Repository) Create(recipe *Recipe) (*Recipe, error) {
+	if err := r.db.Omit("Ingredients.Product").Create(recipe).Error; err != nil {
+		return nil, err
+	}
+	return recipe, nil
+}
+
+// Read retrieves a single recipe by its ID with ingredients and products
+func (r *Repository) Read(id uuid.UUID) (*Recipe, error) {
+	recipe := &Recipe{}
+	if err := preloadProducts(r.db).Where("id = ?", id).First(&recipe).Error; err != nil {
+		return nil, err // Can be gorm.ErrRecordNotFound
+	}
+	return recipe, nil
+}
+
+// Update modifies a recipe and replaces its ingredient list in one transaction
+func (r *Repository) Update(recipe *Recipe) (int64, error) {
+	var rowsAffected int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Recipe{}).
+			Select("RecipeName", "Servings", "CookedWeight", "UpdatedAt").
+			Where("id = ?", recipe.ID).
+			Updates(recipe)
+		if result.Error != nil {
+			return result.Error
+		}
+		rowsAffected = result.RowsAffected
+		if rowsAffected == 0 {
+			return nil
+		}
+
+		if err := tx.Where("recipe_id = ?", recipe.ID).Delete(&Ingredient{}).Error; err != nil {
+			return err
+		}
+		for i := range recipe.Ingredients {
+			recipe.Ingredients[i].RecipeID = recipe.ID
+		}
+		if len(recipe.Ingredients) > 0 {
+			return tx.Omit("Product").Create(&recipe.Ingredients).Error
+		}
+		return nil
+	})
+	return rowsAffected, err
+}
+
+// Delete performs a soft delete on a recipe by its ID
+func (r *Repository) Delete(id uuid.UUID) (int64, error) {
+	result := r.db.Where("id = ?", id).Delete(&Recipe{})
+	return result.RowsAffected, result.Error
+}
+
+// Restore undoes the soft delete of a recipe
+func (r *Repository) Restore(id uuid.UUID) (int64, error) {
+	result := r.db.Unscoped().Model(&Recipe{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	return result.RowsAffected, result.Error
+}
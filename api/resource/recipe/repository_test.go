@@ -0,0 +1,127 @@
+package recipe_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"fitapp-backend/api/resource/common/nutrient"
+	"fitapp-backend/api/resource/product"
+	"fitapp-backend/api/resource/recipe"
+	mockDB "fitapp-backend/mock/db"
+	testUtil "fitapp-backend/util/test"
+)
+
+func TestRepository_Create(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := recipe.NewRepository(db)
+
+	id := uuid.New()
+	userID := uuid.New()
+	ingredientID := uuid.New()
+	productID := uuid.New()
+	newRecipe := &recipe.Recipe{
+		ID:         id,
+		UserID:     userID,
+		RecipeName: "Porridge",
+		Servings:   2,
+		Ingredients: []recipe.Ingredient{
+			{ID: ingredientID, ProductID: productID, Grams: 80},
+		},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "recipes" ("id","created_at","updated_at","deleted_at","user_id","recipe_name","servings","cooked_weight") VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`)).
+		WithArgs(id, mockDB.AnyTime{}, mockDB.AnyTime{}, nil, userID, "Porridge", 2, nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "recipe_ingredients" ("id","created_at","updated_at","deleted_at","recipe_id","product_id","grams") VALUES ($1,$2,$3,$4,$5,$6,$7) ON CONFLICT ("id") DO UPDATE SET "recipe_id"="excluded"."recipe_id"`)).
+		WithArgs(ingredientID, mockDB.AnyTime{}, mockDB.AnyTime{}, nil, id, productID, 80).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	created, err := repo.Create(newRecipe)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, id, created.ID)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Delete(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := recipe.NewRepository(db)
+
+	id := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "recipes" SET "deleted_at"=$1 WHERE id = $2 AND "recipes"."deleted_at" IS NULL`)).
+		WithArgs(mockDB.AnyTime{}, id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	rowsAffected, err := repo.Delete(id)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, int64(1), rowsAffected)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecipe_Nutrition(t *testing.T) {
+	t.Parallel()
+	cookedWeight := 500
+	rc := &recipe.Recipe{
+		Servings:     4,
+		CookedWeight: &cookedWeight,
+		Ingredients: []recipe.Ingredient{
+			{Grams: 200, Product: product.Product{Kcal: decimal.NewFromInt(350), Proteins: decimal.NewFromInt(12), Carbs: decimal.NewFromInt(60), Fats: decimal.NewFromInt(7), Nutrients: nutrient.Set{nutrient.Fiber: decimal.NewFromInt(5)}}},
+			{Grams: 300, Product: product.Product{Kcal: decimal.NewFromInt(60), Proteins: decimal.NewFromInt(3), Carbs: decimal.NewFromInt(5), Fats: decimal.NewFromInt(3), Nutrients: nutrient.Set{nutrient.Fiber: decimal.NewFromInt(1)}}},
+		},
+	}
+
+	// Totals: 880 kcal, 33 g protein, 135 g carbs, 23 g fat
+	testUtil.Equal(t, 500, rc.TotalWeight())
+	per100g := rc.Per100g().ToDto()
+	testUtil.Equal(t, 176.0, per100g.Kcal)
+	testUtil.Equal(t, 6.6, per100g.Proteins)
+	testUtil.Equal(t, 27.0, per100g.Carbs)
+	testUtil.Equal(t, 4.6, per100g.Fats)
+	testUtil.Equal(t, 2.6, per100g.Nutrients[nutrient.Fiber]) // 13 g fiber in 500 g
+
+	perServing := rc.PerServing()
+	testUtil.Equal(t, "8.25", perServing.Proteins.String())
+	testUtil.Equal(t, 220.0, perServing.ToDto().Kcal)
+	testUtil.Equal(t, 8.3, perServing.ToDto().Proteins)
+	testUtil.Equal(t, 33.8, perServing.ToDto().Carbs)
+	testUtil.Equal(t, 5.8, perServing.ToDto().Fats)
+	testUtil.Equal(t, "3.25", perServing.Nutrients[nutrient.Fiber].String())
+	testUtil.Equal(t, 125.0, rc.ServingWeight())
+}
+
+func TestRepository_Read_DeletedProduct(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := recipe.NewRepository(db)
+
+	id := uuid.New()
+	productID := uuid.New()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "recipes" WHERE id = $1 AND "recipes"."deleted_at" IS NULL ORDER BY "recipes"."id" LIMIT $2`)).
+		WithArgs(id, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipe_name", "servings"}).AddRow(id, "Porridge", 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "recipe_ingredients" WHERE "recipe_ingredients"."recipe_id" = $1 AND "recipe_ingredients"."deleted_at" IS NULL`)).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipe_id", "product_id", "grams"}).AddRow(uuid.New(), id, productID, 100))
+	// The product has been deleted since, it still counts towards the recipe
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "products" WHERE "products"."id" = $1`) + "$").
+		WithArgs(productID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "product_name", "kcal", "proteins", "carbs", "fats"}).AddRow(productID, "Oats", "380", "13", "60", "7"))
+
+	found, err := repo.Read(id)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, "380", found.Ingredients[0].Product.Kcal.String())
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
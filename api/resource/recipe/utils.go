@@ -0,0 +1,125 @@
+package recipe
+
+import (
+	"github.com/shopspring/decimal"
+
+	"fitapp-backend/api/resource/common/nutrient"
+)
+
+// RawWeight returns the summed weight of all ingredients in grams
+func (rc *Recipe) RawWeight() int {
+	total := 0
+	for _, ing := range rc.Ingredients {
+		total += ing.Grams
+	}
+	return total
+}
+
+// TotalWeight returns the weight of the finished dish in grams
+func (rc *Recipe) TotalWeight() int {
+	if rc.CookedWeight != nil && *rc.CookedWeight > 0 {
+		return *rc.CookedWeight
+	}
+	return rc.RawWeight()
+}
+
+// totals sums the macros of all ingredients at full precision,
+// computed from the products' per-100g values
+func (rc *Recipe) totals() Nutrition {
+	n := Nutrition{Nutrients: nutrient.Set{}}
+	hundred := decimal.NewFromInt(100)
+	for _, ing := range rc.Ingredients {
+		factor := decimal.NewFromInt(int64(ing.Grams)).Div(hundred)
+		n.Kcal = n.Kcal.Add(ing.Product.Kcal.Mul(factor))
+		n.Proteins = n.Proteins.Add(ing.Product.Proteins.Mul(factor))
+		n.Carbs = n.Carbs.Add(ing.Product.Carbs.Mul(factor))
+		n.Fats = n.Fats.Add(ing.Product.Fats.Mul(factor))
+		n.Nutrients = n.Nutrients.Add(ing.Product.Nutrients.Mul(factor))
+	}
+	return n
+}
+
+// scaled multiplies every value by factor and rounds for storage
+func (n Nutrition) scaled(factor decimal.Decimal) Nutrition {
+	return Nutrition{
+		Kcal:      nutrient.Round(n.Kcal.Mul(factor)),
+		Proteins:  nutrient.Round(n.Proteins.Mul(factor)),
+		Carbs:     nutrient.Round(n.Carbs.Mul(factor)),
+		Fats:      nutrient.Round(n.Fats.Mul(factor)),
+		Nutrients: n.Nutrients.Mul(factor).Round(),
+	}
+}
+
+// ForGrams returns the nutrition of the given amount of the finished dish
+func (rc *Recipe) ForGrams(grams int) Nutrition {
+	weight := rc.TotalWeight()
+	if weight == 0 {
+		return Nutrition{}
+	}
+	factor := decimal.NewFromInt(int64(grams)).Div(decimal.NewFromInt(int64(weight)))
+	return rc.totals().scaled(factor)
+}
+
+// Per100g returns the nutrition of 100 g of the finished dish
+func (rc *Recipe) Per100g() Nutrition {
+	return rc.ForGrams(100)
+}
+
+// ServingWeight returns the weight of a single serving in grams
+func (rc *Recipe) ServingWeight() float64 {
+	if rc.Servings <= 0 {
+		return 0
+	}
+	return float64(rc.TotalWeight()) / float64(rc.Servings)
+}
+
+// PerServing returns the nutrition of a single serving
+func (rc *Recipe) PerServing() Nutrition {
+	if rc.Servings <= 0 {
+		return Nutrition{}
+	}
+	return rc.totals().scaled(decimal.NewFromInt(1).Div(decimal.NewFromInt(int64(rc.Servings))))
+}
+
+// ToDto converts Nutrition to its rounded DTO representation
+func (n Nutrition) ToDto() *NutritionDTO {
+	return &NutritionDTO{
+		Kcal:      nutrient.Kcal(n.Kcal),
+		Proteins:  nutrient.Macro(n.Proteins),
+		Carbs:     nutrient.Macro(n.Carbs),
+		Fats:      nutrient.Macro(n.Fats),
+		Nutrients: n.Nutrients.ToDto(),
+	}
+}
+
+// ToDto converts a Recipe model (with preloaded ingredients) to its DTO representation
+func (rc *Recipe) ToDto() *DTO {
+	ingredients := make([]*IngredientDTO, len(rc.Ingredients))
+	for i, ing := range rc.Ingredients {
+		ingredients[i] = &IngredientDTO{
+			ProductID:   ing.ProductID.String(),
+			ProductName: ing.Product.ProductName,
+			Grams:       ing.Grams,
+		}
+	}
+	return &DTO{
+		ID:           rc.ID.String(),
+		UserID:       rc.UserID.String(),
+		RecipeName:   rc.RecipeName,
+		Servings:     rc.Servings,
+		CookedWeight: rc.CookedWeight,
+		TotalWeight:  rc.TotalWeight(),
+		Ingredients:  ingredients,
+		Per100g:      rc.Per100g().ToDto(),
+		PerServing:   rc.PerServing().ToDto(),
+	}
+}
+
+// ToDto converts a slice of Recipe models to a slice of DTOs
+func (rs Recipes) ToDto() []*DTO {
+	dtos := make([]*DTO, len(rs))
+	for i, rc := range rs {
+		dtos[i] = rc.ToDto()
+	}
+	return dtos
+}
@@ -2,13 +2,19 @@ package user
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10" // Import validator
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+
+	"fitapp-backend/api/resource/common/caller"
+	"fitapp-backend/api/resource/common/energy"
 	// Assuming a shared error handling package exists
 	// "fitapp-backend/internal/err"
 	// "fitapp-backend/pkg/web" // For helper functions like Respond, RespondError
@@ -60,7 +66,7 @@ func formatValidationErrors(err error) map[string]string {
 // List godoc
 //
 //	@summary		List users
-//	@description	List all non-deleted users
+//	@description	List all non-deleted users for admins; other callers only see themselves
 //	@tags			users
 //	@accept			json
 //	@produce		json
@@ -69,7 +75,18 @@ func formatValidationErrors(err error) map[string]string {
 //	@router			/users [get]
 func (a *API) List(w http.ResponseWriter, r *http.Request) {
 	enableCors(&w)
-	users, err := a.repository.List()
+	c, ok := caller.FromRequest(r)
+	if !ok {
+		handleErr(w, http.StatusUnauthorized, "Caller not identified", nil)
+		return
+	}
+	var users Users
+	var err error
+	if c.Admin {
+		users, err = a.repository.List()
+	} else {
+		users, err = a.listSelf(c.UserID)
+	}
 	if err != nil {
 		handleErr(w, http.StatusInternalServerError, "Failed to retrieve users", err.Error())
 		return
@@ -87,20 +104,37 @@ func (a *API) List(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// listSelf lists only the caller's own user, or none if it has been deleted
+func (a *API) listSelf(id uuid.UUID) (Users, error) {
+	self, err := a.repository.Read(id)
+	if err == gorm.ErrRecordNotFound {
+		return Users{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Users{self}, nil
+}
+
 // Create godoc
 //
 //	@summary		Create user
-//	@description	Create a new user with profile details
+//	@description	Create a new user with profile details (admins only; users sign up via /auth/register)
 //	@tags			users
 //	@accept			json
 //	@produce		json
 //	@param			body	body	Form	true	"User creation form"
 //	@success		201	{object}	DTO "Returns the created user"
 //	@failure		400	{object}	map[string]interface{} "Bad Request (Invalid JSON or Validation Errors)"
+//	@failure		403	{object}	map[string]string "Forbidden"
 //	@failure		500	{object}	map[string]string "Internal Server Error"
 //	@router			/users [post]
 func (a *API) Create(w http.ResponseWriter, r *http.Request) {
 	enableCors(&w)
+	if c, ok := caller.FromRequest(r); !ok || !c.Admin {
+		handleErr(w, http.StatusForbidden, "Only admins may create users, sign up via /auth/register", nil)
+		return
+	}
 	form := &Form{}
 	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
 		handleErr(w, http.StatusBadRequest, "Invalid request body JSON", err.Error())
@@ -118,15 +152,16 @@ func (a *API) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if form.Weight == 0 {
+		handleErr(w, http.StatusBadRequest, "Validation failed", map[string]string{"Weight": "field is required"})
+		return
+	}
+
 	// Map form data to the model
-	newUser := &User{
-		ID:       uuid.New(), // Generate new primary key
-		Username: form.Username,
-		FullName: form.FullName,
-		Sex:      *form.Sex, // Dereference pointer after validation ensures it's not nil
-		Height:   form.Height,
-		Weight:   form.Weight,
-		Age:      form.Age,
+	newUser, err := form.ToModel(uuid.New())
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Validation failed", map[string]string{"BirthDate": err.Error()})
+		return
 	}
 
 	createdUser, err := a.repository.Create(newUser)
@@ -186,7 +221,7 @@ func (a *API) Read(w http.ResponseWriter, r *http.Request) {
 // Update godoc
 //
 //	@summary		Update user
-//	@description	Update an existing user's profile details by ID
+//	@description	Update an existing user's profile details by ID; the weight is changed through weight entries
 //	@tags			users
 //	@accept			json
 //	@produce		json
@@ -223,14 +258,10 @@ func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create the model instance for update
-	userToUpdate := &User{
-		ID:       id, // Set ID from path parameter
-		Username: form.Username,
-		FullName: form.FullName,
-		Sex:      *form.Sex,
-		Height:   form.Height,
-		Weight:   form.Weight,
-		Age:      form.Age,
+	userToUpdate, err := form.ToModel(id)
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Validation failed", map[string]string{"BirthDate": err.Error()})
+		return
 	}
 
 	rowsAffected, err := a.repository.Update(userToUpdate)
@@ -275,7 +306,7 @@ func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 // Delete godoc
 //
 //	@summary		Delete user
-//	@description	Soft delete a user by ID
+//	@description	Soft delete a user by ID and revoke all of their sessions
 //	@tags			users
 //	@accept			json
 //	@produce		json
@@ -294,7 +325,7 @@ func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rowsAffected, err := a.repository.Delete(id)
+	rowsAffected, err := a.repository.Delete(id, time.Now())
 	if err != nil {
 		handleErr(w, http.StatusInternalServerError, "Failed to delete user", err.Error())
 		return
@@ -309,3 +340,231 @@ func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "User deleted successfully"})
 }
+
+// Energy godoc
+//
+//	@summary		Estimate energy expenditure
+//	@description	Estimate the user's basal metabolic rate (BMR) from sex, height, weight and age and
+//	@description	multiply it by their activity level to get maintenance calories (TDEE)
+//	@tags			users
+//	@accept			json
+//	@produce		json
+//	@param			id			path		string	true	"User ID (UUID)"
+//	@param			formula		query		string	false	"BMR formula: mifflin_st_jeor (default), harris_benedict or katch_mcardle"
+//	@param			body_fat	query		number	false	"Body fat percentage, required by katch_mcardle"
+//	@success		200	{object}	EnergyDTO
+//	@failure		400	{object}	map[string]string "Bad Request (Invalid ID, formula or body fat)"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/energy [get]
+func (a *API) Energy(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid user ID format", err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+	formula := query.Get("formula")
+	if formula == "" {
+		formula = energy.MifflinStJeor
+	}
+	if !energy.ValidFormula(formula) {
+		handleErr(w, http.StatusBadRequest, "Invalid formula", map[string]string{"formula": "must be one of mifflin_st_jeor, harris_benedict, katch_mcardle"})
+		return
+	}
+	var bodyFat float64
+	if v := query.Get("body_fat"); v != "" {
+		bodyFat, err = strconv.ParseFloat(v, 64)
+		if err != nil || bodyFat <= 0 || bodyFat >= 100 {
+			handleErr(w, http.StatusBadRequest, "Invalid body_fat", map[string]string{"body_fat": "must be a percentage between 0 and 100"})
+			return
+		}
+	}
+
+	user, err := a.repository.Read(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			handleErr(w, http.StatusNotFound, "User not found", nil)
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to read user", err.Error())
+		}
+		return
+	}
+
+	dto, err := user.Energy(formula, bodyFat)
+	if err != nil {
+		if errors.Is(err, energy.ErrBodyFatRequired) {
+			handleErr(w, http.StatusBadRequest, "Invalid body_fat", map[string]string{"body_fat": "is required by katch_mcardle"})
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to estimate energy expenditure", err.Error())
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(dto); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode energy response", err.Error())
+		return
+	}
+}
+
+// AdminList godoc
+//
+//	@summary		List all users (admin)
+//	@description	List all users, including soft-deleted ones with deleted=true
+//	@tags			admin
+//	@accept			json
+//	@produce		json
+//	@security		BearerAuth
+//	@param			deleted	query		bool	false	"Include soft-deleted users"
+//	@success		200	{array}		DTO
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		403	{object}	map[string]string "Forbidden"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/admin/users [get]
+func (a *API) AdminList(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	includeDeleted := false
+	if v := r.URL.Query().Get("deleted"); v != "" {
+		var err error
+		includeDeleted, err = strconv.ParseBool(v)
+		if err != nil {
+			handleErr(w, http.StatusBadRequest, "Invalid deleted parameter (must be a boolean)", err.Error())
+			return
+		}
+	}
+
+	users, err := a.repository.ListAll(includeDeleted)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to retrieve users", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(users.ToDto()); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode users response", err.Error())
+		return
+	}
+}
+
+// Restore godoc
+//
+//	@summary		Restore user (admin)
+//	@description	Undo the soft delete of a user, unless their username has been taken since
+//	@tags			admin
+//	@accept			json
+//	@produce		json
+//	@security		BearerAuth
+//	@param			id	path		string	true	"User ID (UUID)"
+//	@success		200	{object}	DTO "Returns the restored user"
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		403	{object}	map[string]string "Forbidden"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		409	{object}	map[string]string "Username taken by another user"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/admin/users/{id}/restore [post]
+func (a *API) Restore(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid user ID format", err.Error())
+		return
+	}
+
+	deleted, err := a.repository.ReadDeleted(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			handleErr(w, http.StatusNotFound, "Deleted user not found", nil)
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to read user", err.Error())
+		}
+		return
+	}
+	if _, err := a.repository.ReadByUsername(deleted.Username); err == nil {
+		handleErr(w, http.StatusConflict, "Username taken by another user", nil)
+		return
+	} else if err != gorm.ErrRecordNotFound {
+		handleErr(w, http.StatusInternalServerError, "Failed to check username", err.Error())
+		return
+	}
+
+	rowsAffected, err := a.repository.Restore(id)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to restore user", err.Error())
+		return
+	}
+	if rowsAffected == 0 {
+		handleErr(w, http.StatusNotFound, "Deleted user not found", nil)
+		return
+	}
+	restored, err := a.repository.Read(id)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to read restored user", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(restored.ToDto())
+}
+
+// SetRole godoc
+//
+//	@summary		Change user role (admin)
+//	@description	Grant or revoke the admin role. Takes effect when the user's access token is next refreshed.
+//	@tags			admin
+//	@accept			json
+//	@produce		json
+//	@security		BearerAuth
+//	@param			id		path		string		true	"User ID (UUID)"
+//	@param			body	body		RoleForm	true	"Role form"
+//	@success		200	{object}	DTO "Returns the updated user"
+//	@failure		400	{object}	map[string]interface{} "Bad Request (Invalid ID, JSON or role)"
+//	@failure		403	{object}	map[string]string "Forbidden"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		422	{object}	map[string]string "Admins cannot change their own role"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/admin/users/{id}/role [put]
+func (a *API) SetRole(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid user ID format", err.Error())
+		return
+	}
+
+	form := &RoleForm{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid request body JSON", err.Error())
+		return
+	}
+	if err := a.validate.Struct(form); err != nil {
+		handleErr(w, http.StatusBadRequest, "Validation failed", formatValidationErrors(err))
+		return
+	}
+	if c, ok := caller.FromRequest(r); ok && c.UserID == id {
+		handleErr(w, http.StatusUnprocessableEntity, "Admins cannot change their own role", nil) // Keeps at least one admin
+		return
+	}
+
+	rowsAffected, err := a.repository.SetRole(id, form.Role)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to change role", err.Error())
+		return
+	}
+	if rowsAffected == 0 {
+		handleErr(w, http.StatusNotFound, "User not found", nil)
+		return
+	}
+	updated, err := a.repository.Read(id)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to read user", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(updated.ToDto())
+}
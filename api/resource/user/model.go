@@ -17,10 +17,15 @@ type User struct {
 	// Map struct fields to potentially different DB column names
 	Username string `gorm:"column:user_username;not null"`
 	FullName string `gorm:"column:user_full_name;not null"`
-	Sex      bool   `gorm:"column:user_sex;not null"`    // true = male, false = female (by convention)
-	Height   int    `gorm:"column:user_height;not null"` // in cm
-	Weight   int    `gorm:"column:user_weight;not null"` // in kg (or other unit)
-	Age      int    `gorm:"column:user_age;not null"`
+	Sex      bool   `gorm:"column:user_sex;not null"`      // true = male, false = female (by convention)
+	Height   int    `gorm:"column:user_height;not null"`   // in cm
+	Weight   int    `gorm:"column:user_weight;not null"`   // in kg, follows the latest weight entry
+	Timezone string `gorm:"column:user_timezone;not null"` // IANA name, e.g. "Europe/Warsaw"; defines the user's day boundaries
+
+	BirthDate     time.Time `gorm:"column:user_birth_date;type:date;not null"`
+	ActivityLevel string    `gorm:"column:user_activity_level;not null"` // See energy activity levels
+	PasswordHash  string    `gorm:"column:user_password_hash;not null"`  // bcrypt, empty for users created without a password
+	Role          string    `gorm:"column:user_role;not null"`           // See caller roles, only admins change it
 }
 
 // Users is a slice of User pointers
@@ -34,7 +39,13 @@ type DTO struct {
 	Sex      string `json:"sex"` // "male" or "female" or "unknown"
 	Height   int    `json:"height"`
 	Weight   int    `json:"weight"`
-	Age      int    `json:"age"`
+	Timezone string `json:"timezone"`
+
+	BirthDate     string `json:"birth_date"` // YYYY-MM-DD
+	Age           int    `json:"age"`        // Computed from the birth date in the user's timezone
+	ActivityLevel string `json:"activity_level"`
+	Role          string `json:"role"`
+	DeletedAt     string `json:"deleted_at,omitempty"` // RFC3339, only in admin listings of deleted users
 	// Optionally add CreatedAt/UpdatedAt strings if needed
 }
 
@@ -42,10 +53,27 @@ type DTO struct {
 type Form struct {
 	Username string `json:"username" validate:"required"`
 	FullName string `json:"full_name" validate:"required"`
-	Sex      *bool  `json:"sex" validate:"required"`         // Use pointer to distinguish false from nil (not provided)
-	Height   int    `json:"height" validate:"required,gt=0"` // Height must be positive
-	Weight   int    `json:"weight" validate:"required,gt=0"` // Weight must be positive
-	Age      int    `json:"age" validate:"required,gt=0"`    // Age must be positive
+	Sex      *bool  `json:"sex" validate:"required"`                // Use pointer to distinguish false from nil (not provided)
+	Height   int    `json:"height" validate:"required,gt=0"`        // Height must be positive
+	Weight   int    `json:"weight" validate:"omitempty,gt=0"`       // Initial weight, required on creation; later changes go through weight entries
+	Timezone string `json:"timezone" validate:"omitempty,timezone"` // IANA name, defaults to UTC
+
+	BirthDate     string `json:"birth_date" validate:"required,datetime=2006-01-02"`                                    // YYYY-MM-DD, must be in the past
+	ActivityLevel string `json:"activity_level" validate:"omitempty,oneof=sedentary light moderate active very_active"` // Defaults to sedentary
+}
+
+// RoleForm represents the data for changing a user's role
+type RoleForm struct {
+	Role string `json:"role" validate:"required,oneof=user admin"`
+}
+
+// EnergyDTO represents the estimated energy expenditure of a user
+type EnergyDTO struct {
+	Formula         string  `json:"formula"`
+	BMR             int     `json:"bmr"` // kcal per day at rest
+	ActivityLevel   string  `json:"activity_level"`
+	ActivityFactor  float64 `json:"activity_factor"`
+	MaintenanceKcal int     `json:"maintenance_kcal"` // TDEE, kcal per day to maintain weight
 }
 
 // --- TableName (Optional) ---
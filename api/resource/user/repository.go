@@ -1,6 +1,8 @@
 package user
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -27,6 +29,19 @@ func (r *Repository) List() (Users, error) {
 	return users, nil
 }
 
+// ListAll retrieves all users for admins, including soft-deleted ones if requested
+func (r *Repository) ListAll(includeDeleted bool) (Users, error) {
+	users := make([]*User, 0)
+	query := r.db
+	if includeDeleted {
+		query = query.Unscoped()
+	}
+	if err := query.Order("user_username").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 // Create inserts a new user into the database
 func (r *Repository) Create(user *User) (*User, error) {
 	if err := r.db.Create(user).Error; err != nil {
@@ -44,19 +59,68 @@ func (r *Repository) Read(id uuid.UUID) (*User, error) {
 	return user, nil
 }
 
+// ReadByUsername retrieves a single user by their username
+func (r *Repository) ReadByUsername(username string) (*User, error) {
+	user := &User{}
+	if err := r.db.Where("user_username = ?", username).First(&user).Error; err != nil {
+		return nil, err // Can be gorm.ErrRecordNotFound
+	}
+	return user, nil
+}
+
 // Update modifies an existing user in the database
 func (r *Repository) Update(user *User) (int64, error) {
-	// Specify fields allowed to be updated using GORM struct field names
+	// Specify fields allowed to be updated using GORM struct field names.
+	// Weight is left out, it follows the latest weight entry.
 	result := r.db.Model(&User{}).
-		Select("Username", "FullName", "Sex", "Height", "Weight", "Age", "UpdatedAt").
+		Select("Username", "FullName", "Sex", "Height", "Timezone", "BirthDate", "ActivityLevel", "UpdatedAt").
 		Where("id = ?", user.ID).
 		Updates(user) // GORM handles mapping to correct DB columns
 
 	return result.RowsAffected, result.Error
 }
 
-// Delete performs a soft delete on a user by its ID
-func (r *Repository) Delete(id uuid.UUID) (int64, error) {
-	result := r.db.Where("id = ?", id).Delete(&User{})
+// SetRole changes the role of a user
+func (r *Repository) SetRole(id uuid.UUID, role string) (int64, error) {
+	result := r.db.Model(&User{}).Where("id = ?", id).Update("Role", role)
+	return result.RowsAffected, result.Error
+}
+
+// ReadDeleted retrieves a soft-deleted user by its ID
+func (r *Repository) ReadDeleted(id uuid.UUID) (*User, error) {
+	user := &User{}
+	if err := r.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&user).Error; err != nil {
+		return nil, err // Can be gorm.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+// Restore undoes the soft delete of a user
+func (r *Repository) Restore(id uuid.UUID) (int64, error) {
+	result := r.db.Unscoped().Model(&User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
 	return result.RowsAffected, result.Error
 }
+
+// Delete performs a soft delete on a user by its ID
+func (r *Repository) Delete(id uuid.UUID, now time.Time) (int64, error) {
+	var rowsAffected int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ?", id).Delete(&User{})
+		if result.Error != nil {
+			return result.Error
+		}
+		rowsAffected = result.RowsAffected
+		if rowsAffected == 0 {
+			return nil
+		}
+
+		// Revoked sessions reject their refresh tokens and, once cached state
+		// expires, their access tokens
+		return tx.Table("sessions").
+			Where("user_id = ? AND revoked_at IS NULL", id).
+			Updates(map[string]interface{}{"revoked_at": now, "updated_at": now}).Error
+	})
+	return rowsAffected, err
+}
@@ -1,6 +1,7 @@
 package user_test
 
 import (
+	"fitapp-backend/api/resource/common/energy"
 	"fitapp-backend/api/resource/user"  // Adjust import path
 	mockDB "fitapp-backend/mock/db"     // Adjust import path
 	testUtil "fitapp-backend/util/test" // Adjust import path
@@ -16,7 +17,7 @@ import (
 // Updated list of columns reflecting the new schema and GORM mappings
 var userColumns = []string{
 	"id", "created_at", "updated_at", "deleted_at",
-	"user_username", "user_full_name", "user_sex", "user_height", "user_weight", "user_age",
+	"user_username", "user_full_name", "user_sex", "user_height", "user_weight", "user_timezone", "user_birth_date", "user_activity_level", "user_password_hash", "user_role",
 }
 
 func TestRepository_List(t *testing.T) {
@@ -30,8 +31,8 @@ func TestRepository_List(t *testing.T) {
 	userID2 := uuid.New()
 
 	mockRows := sqlmock.NewRows(userColumns).
-		AddRow(userID1, now, now, gorm.DeletedAt{}, "user1", "Full Name One", true, 180, 80, 30). // Sex=true (male)
-		AddRow(userID2, now, now, gorm.DeletedAt{}, "user2", "Full Name Two", false, 165, 60, 25) // Sex=false (female)
+		AddRow(userID1, now, now, gorm.DeletedAt{}, "user1", "Full Name One", true, 180, 80, "UTC", time.Date(1994, 5, 12, 0, 0, 0, 0, time.UTC), "moderate", "", "user"). // Sex=true (male)
+		AddRow(userID2, now, now, gorm.DeletedAt{}, "user2", "Full Name Two", false, 165, 60, "America/New_York", time.Date(1999, 11, 3, 0, 0, 0, 0, time.UTC), "sedentary", "", "user") // Sex=false (female)
 
 	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "users" WHERE "users"."deleted_at" IS NULL`)
 	mock.ExpectQuery(expectedSQL).WillReturnRows(mockRows)
@@ -56,12 +57,15 @@ func TestRepository_Create(t *testing.T) {
 		Sex:      true, // Male
 		Height:   175,
 		Weight:   75,
-		Age:      28,
+		Timezone: "Europe/Warsaw",
+
+		BirthDate:     time.Date(1996, 2, 29, 0, 0, 0, 0, time.UTC),
+		ActivityLevel: "light",
 	}
 
 	mock.ExpectBegin()
 	// Match the column order GORM uses for INSERT (check generated SQL if needed)
-	expectedSQL := regexp.QuoteMeta(`INSERT INTO "users" ("id","created_at","updated_at","deleted_at","user_username","user_full_name","user_sex","user_height","user_weight","user_age") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)`)
+	expectedSQL := regexp.QuoteMeta(`INSERT INTO "users" ("id","created_at","updated_at","deleted_at","user_username","user_full_name","user_sex","user_height","user_weight","user_timezone","user_birth_date","user_activity_level","user_password_hash","user_role") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14)`)
 	mock.ExpectExec(expectedSQL).
 		WithArgs(
 			newUser.ID,
@@ -73,7 +77,11 @@ func TestRepository_Create(t *testing.T) {
 			newUser.Sex,
 			newUser.Height,
 			newUser.Weight,
-			newUser.Age,
+			newUser.Timezone,
+			newUser.BirthDate,
+			newUser.ActivityLevel,
+			newUser.PasswordHash,
+			newUser.Role,
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
@@ -96,10 +104,10 @@ func TestRepository_Read(t *testing.T) {
 	expectedHeight := 190
 
 	mockRows := sqlmock.NewRows(userColumns).
-		AddRow(id, time.Now(), time.Now(), gorm.DeletedAt{}, expectedUsername, "Read User Name", true, expectedHeight, 90, 40)
+		AddRow(id, time.Now(), time.Now(), gorm.DeletedAt{}, expectedUsername, "Read User Name", true, expectedHeight, 90, "Asia/Tokyo", time.Date(1984, 1, 7, 0, 0, 0, 0, time.UTC), "active", "", "user")
 
-	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "users" WHERE id = $1 AND "users"."deleted_at" IS NULL ORDER BY "users"."id" LIMIT 1`)
-	mock.ExpectQuery(expectedSQL).WithArgs(id).WillReturnRows(mockRows)
+	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "users" WHERE id = $1 AND "users"."deleted_at" IS NULL ORDER BY "users"."id" LIMIT $2`)
+	mock.ExpectQuery(expectedSQL).WithArgs(id, 1).WillReturnRows(mockRows)
 
 	found, err := repo.Read(id)
 	testUtil.NoError(t, err)
@@ -107,6 +115,7 @@ func TestRepository_Read(t *testing.T) {
 	testUtil.Equal(t, id, found.ID)
 	testUtil.Equal(t, expectedUsername, found.Username)
 	testUtil.Equal(t, expectedHeight, found.Height)
+	testUtil.Equal(t, "Asia/Tokyo", found.Location().String())
 	testUtil.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -124,21 +133,25 @@ func TestRepository_Update(t *testing.T) {
 		Sex:      false, // Female
 		Height:   168,
 		Weight:   65,
-		Age:      31,
+		Timezone: "UTC",
+
+		BirthDate:     time.Date(1993, 8, 21, 0, 0, 0, 0, time.UTC),
+		ActivityLevel: "very_active",
 	}
 
 	mock.ExpectBegin()
-	// Match the fields selected in Repository.Update
-	expectedSQL := regexp.QuoteMeta(`UPDATE "users" SET "user_username"=$1,"user_full_name"=$2,"user_sex"=$3,"user_height"=$4,"user_weight"=$5,"user_age"=$6,"updated_at"=$7 WHERE id = $8`)
+	// Match the fields selected in Repository.Update; the weight follows the weight entries and is not written
+	expectedSQL := regexp.QuoteMeta(`UPDATE "users" SET "updated_at"=$1,"user_username"=$2,"user_full_name"=$3,"user_sex"=$4,"user_height"=$5,"user_timezone"=$6,"user_birth_date"=$7,"user_activity_level"=$8 WHERE id = $9 AND "users"."deleted_at" IS NULL`)
 	mock.ExpectExec(expectedSQL).
 		WithArgs(
+			mockDB.AnyTime{}, // UpdatedAt
 			userToUpdate.Username,
 			userToUpdate.FullName,
 			userToUpdate.Sex,
 			userToUpdate.Height,
-			userToUpdate.Weight,
-			userToUpdate.Age,
-			mockDB.AnyTime{}, // UpdatedAt
+			userToUpdate.Timezone,
+			userToUpdate.BirthDate,
+			userToUpdate.ActivityLevel,
 			id,               // WHERE id = ?
 		).
 		WillReturnResult(sqlmock.NewResult(0, 1)) // 1 row affected
@@ -157,15 +170,150 @@ func TestRepository_Delete(t *testing.T) {
 	repo := user.NewRepository(db)
 
 	id := uuid.New()
+	now := time.Now()
 
 	mock.ExpectBegin()
 	expectedSQL := regexp.QuoteMeta(`UPDATE "users" SET "deleted_at"=$1 WHERE id = $2 AND "users"."deleted_at" IS NULL`)
 	mock.ExpectExec(expectedSQL).
 		WithArgs(mockDB.AnyTime{}, id).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	// The user's sessions are revoked in the same transaction
+	expectedSQL = regexp.QuoteMeta(`UPDATE "sessions" SET "revoked_at"=$1,"updated_at"=$2 WHERE user_id = $3 AND revoked_at IS NULL`)
+	mock.ExpectExec(expectedSQL).
+		WithArgs(now, now, id).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	rowsAffected, err := repo.Delete(id, now)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, int64(1), rowsAffected)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUser_DateAt(t *testing.T) {
+	t.Parallel()
+	// 00:30 in Warsaw is still the previous day in UTC during summer time
+	instant := time.Date(2024, 6, 30, 22, 30, 0, 0, time.UTC)
+
+	warsaw := &user.User{Timezone: "Europe/Warsaw"}
+	testUtil.Equal(t, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), warsaw.DateAt(instant))
+
+	utc := &user.User{}
+	testUtil.Equal(t, time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC), utc.DateAt(instant))
+
+	honolulu := &user.User{Timezone: "Pacific/Honolulu"}
+	testUtil.Equal(t, time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC), honolulu.DateAt(instant))
+}
+
+func TestUser_Energy(t *testing.T) {
+	t.Parallel()
+	// Turned 30 yesterday, so the age does not depend on when the test runs
+	u := &user.User{Sex: true, Height: 180, Weight: 80, BirthDate: bornYearsAgo(30), ActivityLevel: energy.Moderate}
+
+	// Mifflin-St Jeor: 10*80 + 6.25*180 - 5*30 + 5 = 1780
+	dto, err := u.Energy(energy.MifflinStJeor, 0)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 1780, dto.BMR)
+	testUtil.Equal(t, 2759, dto.MaintenanceKcal)
+
+	dto, err = u.Energy(energy.HarrisBenedict, 0)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 1854, dto.BMR)
+
+	// Katch-McArdle: 370 + 21.6 * 80 * 0.8 = 1752.4
+	_, err = u.Energy(energy.KatchMcArdle, 0)
+	testUtil.ErrorIs(t, err, energy.ErrBodyFatRequired)
+	dto, err = u.Energy(energy.KatchMcArdle, 20)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 1752, dto.BMR)
+
+	// Users without an activity level are treated as sedentary
+	female := &user.User{Sex: false, Height: 165, Weight: 60, BirthDate: bornYearsAgo(25)}
+	dto, err = female.Energy(energy.MifflinStJeor, 0)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 1345, dto.BMR)
+	testUtil.Equal(t, energy.Sedentary, dto.ActivityLevel)
+	testUtil.Equal(t, 1614, dto.MaintenanceKcal)
+}
+
+// bornYearsAgo returns the birth date of a UTC user who turned years old yesterday
+func bornYearsAgo(years int) time.Time {
+	return (&user.User{}).Today().AddDate(-years, 0, -1)
+}
+
+func TestUser_Age(t *testing.T) {
+	t.Parallel()
+	leapling := &user.User{BirthDate: time.Date(2000, 2, 29, 0, 0, 0, 0, time.UTC)}
+	testUtil.Equal(t, 22, leapling.AgeOn(time.Date(2023, 2, 28, 0, 0, 0, 0, time.UTC)))
+	testUtil.Equal(t, 23, leapling.AgeOn(time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)))
+	testUtil.Equal(t, 24, leapling.AgeOn(time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)))
+
+	dto := (&user.User{BirthDate: bornYearsAgo(41)}).ToDto()
+	testUtil.Equal(t, 41, dto.Age)
+}
+
+func TestRepository_ReadByUsername(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := user.NewRepository(db)
+
+	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "users" WHERE user_username = $1 AND "users"."deleted_at" IS NULL ORDER BY "users"."id" LIMIT $2`)
+	mock.ExpectQuery(expectedSQL).WithArgs("ghost", 1).WillReturnRows(sqlmock.NewRows(userColumns))
+
+	_, err = repo.ReadByUsername("ghost")
+	testUtil.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUser_Password(t *testing.T) {
+	t.Parallel()
+	u := &user.User{}
+	testUtil.Equal(t, false, u.CheckPassword("")) // Created without a password
+
+	testUtil.NoError(t, u.SetPassword("correct horse battery"))
+	testUtil.Equal(t, true, u.PasswordHash != "correct horse battery")
+	testUtil.Equal(t, true, u.CheckPassword("correct horse battery"))
+	testUtil.Equal(t, false, u.CheckPassword("Correct horse battery"))
+}
+
+func TestRepository_Restore(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := user.NewRepository(db)
+
+	id := uuid.New()
+
+	mock.ExpectBegin()
+	expectedSQL := regexp.QuoteMeta(`UPDATE "users" SET "deleted_at"=$1,"updated_at"=$2 WHERE id = $3 AND deleted_at IS NOT NULL`)
+	mock.ExpectExec(expectedSQL).
+		WithArgs(nil, mockDB.AnyTime{}, id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	rowsAffected, err := repo.Restore(id)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, int64(1), rowsAffected)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_SetRole(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := user.NewRepository(db)
+
+	id := uuid.New()
+
+	mock.ExpectBegin()
+	expectedSQL := regexp.QuoteMeta(`UPDATE "users" SET "user_role"=$1,"updated_at"=$2 WHERE id = $3 AND "users"."deleted_at" IS NULL`)
+	mock.ExpectExec(expectedSQL).
+		WithArgs("admin", mockDB.AnyTime{}, id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectCommit()
 
-	rowsAffected, err := repo.Delete(id)
+	rowsAffected, err := repo.SetRole(id, "admin")
 	testUtil.NoError(t, err)
 	testUtil.Equal(t, int64(1), rowsAffected)
 	testUtil.NoError(t, mock.ExpectationsWereMet())
@@ -1,5 +1,90 @@
 package user
 
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"fitapp-backend/api/resource/common/caller"
+	"fitapp-backend/api/resource/common/energy"
+)
+
+// DefaultTimezone is used when a user does not specify a timezone
+const DefaultTimezone = "UTC"
+
+// timezoneOrDefault returns the form's timezone, or DefaultTimezone if empty
+func timezoneOrDefault(name string) string {
+	if name == "" {
+		return DefaultTimezone
+	}
+	return name
+}
+
+// Location returns the user's timezone, falling back to UTC for unknown names
+func (u *User) Location() *time.Location {
+	loc, err := time.LoadLocation(timezoneOrDefault(u.Timezone))
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// DateAt returns the user's calendar date at the instant t, as midnight UTC
+// like dates parsed from the API
+func (u *User) DateAt(t time.Time) time.Time {
+	y, m, d := t.In(u.Location()).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Today returns the user's current calendar date
+func (u *User) Today() time.Time {
+	return u.DateAt(time.Now())
+}
+
+// BirthDateFormat is the format of birth dates in the API
+const BirthDateFormat = time.DateOnly
+
+// MaxAge is the oldest age a birth date may imply
+const MaxAge = 130
+
+// ErrInvalidBirthDate is returned for birth dates in the future or implausibly far in the past
+var ErrInvalidBirthDate = errors.New("birth date must be in the past and imply an age of at most 130")
+
+// parseBirthDate parses a birth date and checks it against the user's today
+func parseBirthDate(value string, today time.Time) (time.Time, error) {
+	birthDate, err := time.Parse(BirthDateFormat, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !birthDate.Before(today) || ageOn(birthDate, today) > MaxAge {
+		return time.Time{}, ErrInvalidBirthDate
+	}
+	return birthDate, nil
+}
+
+// ageOn returns the number of full years between birthDate and date
+func ageOn(birthDate, date time.Time) int {
+	age := date.Year() - birthDate.Year()
+	if date.Month() < birthDate.Month() || (date.Month() == birthDate.Month() && date.Day() < birthDate.Day()) {
+		age-- // Birthday not reached yet this year
+	}
+	return age
+}
+
+// AgeOn returns the user's age in full years on the date
+func (u *User) AgeOn(date time.Time) int {
+	return ageOn(u.BirthDate, date)
+}
+
+// Age returns the user's current age, changing on their birthday in their timezone
+func (u *User) Age() int {
+	return u.AgeOn(u.Today())
+}
+
 // Helper function to convert boolean Sex to string representation
 func sexToString(sex bool) string {
 	if sex {
@@ -8,6 +93,49 @@ func sexToString(sex bool) string {
 	return "female"
 }
 
+// activityLevelOrDefault returns the activity level, or the default if empty
+func activityLevelOrDefault(level string) string {
+	if level == "" {
+		return energy.DefaultActivityLevel
+	}
+	return level
+}
+
+// Profile returns the body data used to estimate the user's energy expenditure.
+// bodyFat is the body fat percentage, 0 if unknown.
+func (u *User) Profile(bodyFat float64) energy.Profile {
+	return energy.Profile{
+		Male:     u.Sex,
+		HeightCm: float64(u.Height),
+		WeightKg: float64(u.Weight),
+		Age:      u.Age(),
+		BodyFat:  bodyFat,
+	}
+}
+
+// Energy estimates the user's BMR with the formula and their maintenance calories
+// at their activity level
+func (u *User) Energy(formula string, bodyFat float64) (*EnergyDTO, error) {
+	bmr, err := energy.BMR(formula, u.Profile(bodyFat))
+	if err != nil {
+		return nil, err
+	}
+	level := activityLevelOrDefault(u.ActivityLevel)
+	tdee, ok := energy.TDEE(bmr, level)
+	if !ok {
+		level = energy.DefaultActivityLevel
+		tdee, _ = energy.TDEE(bmr, level)
+	}
+	factor, _ := energy.ActivityFactor(level)
+	return &EnergyDTO{
+		Formula:         formula,
+		BMR:             int(math.Round(bmr)),
+		ActivityLevel:   level,
+		ActivityFactor:  factor,
+		MaintenanceKcal: int(math.Round(tdee)),
+	}, nil
+}
+
 // ToDto converts a User model to its DTO representation
 func (u *User) ToDto() *DTO {
 	return &DTO{
@@ -17,8 +145,30 @@ func (u *User) ToDto() *DTO {
 		Sex:      sexToString(u.Sex), // Convert bool to string
 		Height:   u.Height,
 		Weight:   u.Weight,
-		Age:      u.Age,
+		Timezone: timezoneOrDefault(u.Timezone),
+
+		BirthDate:     u.BirthDate.Format(BirthDateFormat),
+		Age:           u.Age(),
+		ActivityLevel: activityLevelOrDefault(u.ActivityLevel),
+		Role:          roleOrDefault(u.Role),
+		DeletedAt:     deletedAt(u.DeletedAt),
+	}
+}
+
+// roleOrDefault returns the role, or the regular user role if empty
+func roleOrDefault(role string) string {
+	if role == "" {
+		return caller.RoleUser
 	}
+	return role
+}
+
+// deletedAt formats the soft delete time, empty for users which are not deleted
+func deletedAt(d gorm.DeletedAt) string {
+	if !d.Valid {
+		return ""
+	}
+	return d.Time.UTC().Format(time.RFC3339)
 }
 
 // ToDto converts a slice of User models to a slice of DTOs
@@ -30,21 +180,44 @@ func (us Users) ToDto() []*DTO {
 	return dtos
 }
 
-// --- Note: ToModel logic is now handled within the handler for better validation and pointer handling ---
-/*
-// Example ToModel (if parsing handled differently):
-func (f *Form) ToModel() (*User, error) {
-	if f.Sex == nil {
-		// Handle missing required field - this should ideally be caught by validation
-		return nil, errors.New("sex field is required")
-	}
-	return &User{
+// ToModel maps a validated form, whose Sex is set, to a user with the given ID.
+// The birth date is checked against today in the user's timezone.
+func (f *Form) ToModel(id uuid.UUID) (*User, error) {
+	u := &User{
+		ID:       id,
 		Username: f.Username,
 		FullName: f.FullName,
-		Sex:      *f.Sex, // Dereference pointer
+		Sex:      *f.Sex,
 		Height:   f.Height,
 		Weight:   f.Weight,
-		Age:      f.Age,
-	}, nil
+		Timezone: timezoneOrDefault(f.Timezone),
+
+		ActivityLevel: activityLevelOrDefault(f.ActivityLevel),
+		Role:          caller.RoleUser, // Only admins grant other roles
+	}
+	birthDate, err := parseBirthDate(f.BirthDate, u.Today())
+	if err != nil {
+		return nil, err
+	}
+	u.BirthDate = birthDate
+	return u, nil
+}
+
+// SetPassword stores a bcrypt hash of the password
+func (u *User) SetPassword(password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err // bcrypt.ErrPasswordTooLong for more than 72 bytes
+	}
+	u.PasswordHash = string(hash)
+	return nil
+}
+
+// CheckPassword reports whether the password matches the stored hash.
+// Users created without a password cannot log in.
+func (u *User) CheckPassword(password string) bool {
+	if u.PasswordHash == "" {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) == nil
 }
-*/
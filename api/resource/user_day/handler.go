@@ -2,6 +2,7 @@ package userday
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -9,19 +10,27 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+
+	"fitapp-backend/api/resource/common/caller"
+	"fitapp-backend/api/resource/goal"
+	"fitapp-backend/api/resource/user"
 	// Assuming a shared error handling package exists
 	// "fitapp-backend/internal/err"
 )
 
 // API holds the dependencies for the userday handlers
 type API struct {
-	repository *Repository
+	repository     *Repository
+	userRepository *user.Repository
+	goalAPI        *goal.API
 }
 
 // New creates a new API instance for userday routes
 func New(db *gorm.DB) *API {
 	return &API{
-		repository: NewRepository(db),
+		repository:     NewRepository(db),
+		userRepository: user.NewRepository(db),
+		goalAPI:        goal.New(db),
 	}
 }
 
@@ -43,148 +52,133 @@ func enableCors(w *http.ResponseWriter) {
 
 // --- TODO: Implement proper validation ---
 
-// List godoc
-//
-//	@summary		List user days
-//	@description	List all user day records (consider pagination)
-//	@tags			user-days
-//	@accept			json
-//	@produce		json
-//	@success		200	{array}		DTO
-//	@failure		500	{object}	map[string]string "Internal Server Error"
-//	@router			/user-days [get]
-func (a *API) List(w http.ResponseWriter, r *http.Request) {
-	enableCors(&w)
-	userDays, err := a.repository.List()
+// Today returns the user's current date in their timezone
+func (a *API) Today(userID uuid.UUID) (time.Time, error) {
+	u, err := a.userRepository.Read(userID)
 	if err != nil {
-		handleErr(w, http.StatusInternalServerError, "Failed to retrieve user days", err)
-		return
+		return time.Time{}, err // Can be gorm.ErrRecordNotFound
 	}
+	return u.Today(), nil
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	if len(userDays) == 0 {
-		fmt.Fprint(w, "[]")
-		return
+// ParseDate parses a date in DateFormat, or resolves Today for the user.
+// Invalid values are reported as ErrInvalidDate.
+func (a *API) ParseDate(userID uuid.UUID, value string) (time.Time, error) {
+	if value == Today {
+		return a.Today(userID)
 	}
-
-	if err := json.NewEncoder(w).Encode(userDays.ToDto()); err != nil {
-		handleErr(w, http.StatusInternalServerError, "Failed to encode response", err)
-		return
+	date, err := time.Parse(DateFormat, value)
+	if err != nil {
+		return time.Time{}, invalidDate(err)
 	}
+	return date, nil
 }
 
-// Create godoc
-//
-//	@summary		Create user day
-//	@description	Create a new user day record
-//	@tags			user-days
-//	@accept			json
-//	@produce		json
-//	@param			body	body	Form	true	"User Day form"
-//	@success		201	{object}	DTO "Returns the created user day"
-//	@failure		400	{object}	map[string]string "Bad Request" // e.g., Invalid JSON, UUID, Date format
-//	@failure		422	{object}	map[string]string "Unprocessable Entity" // e.g., Validation errors, Duplicate entry
-//	@failure		500	{object}	map[string]string "Internal Server Error"
-//	@router			/user-days [post]
-func (a *API) Create(w http.ResponseWriter, r *http.Request) {
-	enableCors(&w)
-	form := &Form{}
-	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
-		handleErr(w, http.StatusBadRequest, "Invalid request body JSON", err)
-		return
+// WriteDateErr writes the response for an error returned by ParseDate, naming
+// the request field the date was read from
+func WriteDateErr(w http.ResponseWriter, field string, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidDate):
+		handleErr(w, http.StatusBadRequest, fmt.Sprintf("Invalid %s format (must be %s or %q)", field, DateFormat, Today), err)
+	case err == gorm.ErrRecordNotFound:
+		handleErr(w, http.StatusNotFound, "User not found", err)
+	default:
+		handleErr(w, http.StatusInternalServerError, "Failed to resolve user date", err)
 	}
+}
 
-	// --- Parse and Validate Input ---
-	userID, err := uuid.Parse(form.UserID)
+// toDtoWithMeals converts a UserDay to its DTO including per-meal subtotals
+// and the goal in effect on that date
+func (a *API) toDtoWithMeals(userDay *UserDay) (*DTO, error) {
+	dto := userDay.ToDto()
+	meals, err := a.repository.MealTotals(userDay.UserID, userDay.UserDate)
 	if err != nil {
-		handleErr(w, http.StatusBadRequest, "Invalid UserID format (must be UUID)", err)
-		return
+		return nil, err
+	}
+	dto.Meals = make([]*MealTotalDTO, len(meals))
+	for i, mt := range meals {
+		dto.Meals[i] = mt.ToDto()
 	}
 
-	userDate, err := time.Parse(DateFormat, form.UserDate)
+	u, err := a.userRepository.Read(userDay.UserID)
 	if err != nil {
-		handleErr(w, http.StatusBadRequest, fmt.Sprintf("Invalid UserDate format (must be %s)", DateFormat), err)
-		return
+		return nil, err
 	}
-
-	// --- TODO: Add more validation if needed (e.g., kcal >= 0) ---
-	// Example: if form.DailyKcal < 0 { handleErr(w, http.StatusUnprocessableEntity, "kcal cannot be negative", nil); return }
-
-	newUserDay := &UserDay{
-		ID:            uuid.New(), // Generate new primary key
-		UserID:        userID,
-		UserDate:      userDate,
-		DailyKcal:     form.DailyKcal,
-		DailyProteins: form.DailyProteins,
-		DailyCarbs:    form.DailyCarbs,
-		DailyFats:     form.DailyFats,
+	target, err := a.goalAPI.TargetOn(u, userDay.UserDate)
+	if err != nil {
+		return nil, err
 	}
+	if target != nil {
+		dto.Target = target.ToDto()
+		dto.Remaining = target.Remaining(userDay.DailyKcal, userDay.DailyProteins, userDay.DailyCarbs, userDay.DailyFats).ToDto()
+	}
+	return dto, nil
+}
 
-	createdUserDay, err := a.repository.Create(newUserDay)
+// readOwned loads the user day of the id path parameter if the caller may
+// access it; other users' days are reported as not found.
+// Returns nil if an error response has been written.
+func (a *API) readOwned(w http.ResponseWriter, r *http.Request) *UserDay {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
-		// Check for specific DB errors like unique constraint violation
-		// Example: if strings.Contains(err.Error(), "UNIQUE constraint failed") { ... }
-		handleErr(w, http.StatusUnprocessableEntity, "Failed to create user day (possible duplicate UserID/Date?)", err)
-		return
+		handleErr(w, http.StatusBadRequest, "Invalid ID format (must be UUID)", err)
+		return nil
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(createdUserDay.ToDto()); err != nil {
-		// Log error, but the resource was created
-		fmt.Printf("Error encoding created user_day response: %v\n", err)
+	userDay, err := a.repository.Read(id)
+	if err == nil && !caller.CanAccess(r, userDay.UserID) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			handleErr(w, http.StatusNotFound, "User day record not found", err)
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to read user day", err)
+		}
+		return nil
 	}
+	return userDay
 }
 
-func (a *API) Create_from_product(uid string, kcal int, proteins int, carbs int, fats int) {
-
-	fmt.Print("chujjj")
-	userID, err := uuid.Parse(uid)
+// List godoc
+//
+//	@summary		List user days
+//	@description	List the caller's user day records; admins see all records (consider pagination)
+//	@tags			user-days
+//	@accept			json
+//	@produce		json
+//	@success		200	{array}		DTO
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/user-days [get]
+func (a *API) List(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	c, ok := caller.FromRequest(r)
+	if !ok {
+		handleErr(w, http.StatusUnauthorized, "Caller not identified", nil)
+		return
+	}
+	var userDays UserDays
+	var err error
+	if c.Admin {
+		userDays, err = a.repository.List()
+	} else {
+		userDays, err = a.repository.ListByUser(c.UserID)
+	}
 	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to retrieve user days", err)
 		return
 	}
 
-	toUpdate, err := a.repository.FindByUserAndDate(userID, time.Now())
-	if err == nil {
-		fmt.Print("Already exists")
-		userDayToUpdate := &UserDay{
-			ID:            toUpdate.ID,
-			DailyKcal:     toUpdate.DailyKcal + kcal,
-			DailyProteins: toUpdate.DailyProteins + proteins,
-			DailyCarbs:    toUpdate.DailyCarbs + carbs,
-			DailyFats:     toUpdate.DailyFats + fats,
-			// UserID and UserDate are NOT updated via this method
-		}
-
-		rowsAffected, err := a.repository.Update(userDayToUpdate)
-		if err != nil {
-			fmt.Print("Failed to update user day")
-			return
-		}
-
-		if rowsAffected == 0 {
-			// Could be not found, or data was identical. Assume not found for simplicity.
-			fmt.Print("User day record not found or no changes detected")
-			return
-		}
+	w.Header().Set("Content-Type", "application/json")
+	if len(userDays) == 0 {
+		fmt.Fprint(w, "[]")
 		return
 	}
 
-	newUserDay := &UserDay{
-		ID:            uuid.New(), // Generate new primary key
-		UserID:        userID,
-		UserDate:      time.Now(),
-		DailyKcal:     kcal,
-		DailyProteins: proteins,
-		DailyCarbs:    carbs,
-		DailyFats:     fats,
-	}
-	if _, err := a.repository.Create(newUserDay); err != nil {
-		// Log error, but the resource was created
-		fmt.Printf("Error encoding created user_day response: %v\n", err)
+	if err := json.NewEncoder(w).Encode(userDays.ToDto()); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode response", err)
+		return
 	}
-
-	fmt.Printf("%v", newUserDay)
 }
 
 // Read godoc
@@ -202,25 +196,19 @@ func (a *API) Create_from_product(uid string, kcal int, proteins int, carbs int,
 //	@router			/user-days/{id} [get]
 func (a *API) Read(w http.ResponseWriter, r *http.Request) {
 	enableCors(&w)
-	paramValue := chi.URLParam(r, "id")
-	id, err := uuid.Parse(paramValue)
-	if err != nil {
-		handleErr(w, http.StatusBadRequest, "Invalid ID format (must be UUID)", err)
+	userDay := a.readOwned(w, r)
+	if userDay == nil {
 		return
 	}
 
-	userDay, err := a.repository.Read(id)
+	dto, err := a.toDtoWithMeals(userDay)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			handleErr(w, http.StatusNotFound, "User day record not found", err)
-		} else {
-			handleErr(w, http.StatusInternalServerError, "Failed to read user day", err)
-		}
+		handleErr(w, http.StatusInternalServerError, "Failed to compute meal subtotals and goal", err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(userDay.ToDto()); err != nil {
+	if err := json.NewEncoder(w).Encode(dto); err != nil {
 		handleErr(w, http.StatusInternalServerError, "Failed to encode response", err)
 		return
 	}
@@ -234,7 +222,7 @@ func (a *API) Read(w http.ResponseWriter, r *http.Request) {
 //	@accept			json
 //	@produce		json
 //	@param			userId	query		string	true	"User ID (UUID)" format(uuid)
-//	@param			date	query		string	true	"Date (YYYY-MM-DD)" format(date)
+//	@param			date	query		string	true	"Date (YYYY-MM-DD or today)"
 //	@success		200	{object}	DTO
 //	@failure		400	{object}	map[string]string "Bad Request" // e.g., Missing/Invalid query params or format
 //	@failure		404	{object}	map[string]string "Not Found"
@@ -255,10 +243,14 @@ func (a *API) FindByUserAndDate(w http.ResponseWriter, r *http.Request) {
 		handleErr(w, http.StatusBadRequest, "Invalid userId format (must be UUID)", err)
 		return
 	}
+	if !caller.CanAccess(r, userID) {
+		handleErr(w, http.StatusNotFound, "User day record not found for specified user and date", nil)
+		return
+	}
 
-	userDate, err := time.Parse(DateFormat, dateParam)
+	userDate, err := a.ParseDate(userID, dateParam)
 	if err != nil {
-		handleErr(w, http.StatusBadRequest, fmt.Sprintf("Invalid date format (must be %s)", DateFormat), err)
+		WriteDateErr(w, "date", err)
 		return
 	}
 
@@ -272,70 +264,17 @@ func (a *API) FindByUserAndDate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(userDay.ToDto()); err != nil {
-		handleErr(w, http.StatusInternalServerError, "Failed to encode response", err)
-		return
-	}
-}
-
-// Update godoc
-//
-//	@summary		Update user day
-//	@description	Update nutritional info for an existing user day record by its primary ID
-//	@tags			user-days
-//	@accept			json
-//	@produce		json
-//	@param			id		path	string	true	"UserDay Primary ID (UUID)"
-//	@param			body	body	Form	true	"User Day form (only nutritional fields are used)"
-//	@success		200		{object}	map[string]string "Successfully updated"
-//	@failure		400	{object}	map[string]string "Bad Request" // e.g., Invalid ID/JSON
-//	@failure		404	{object}	map[string]string "Not Found"
-//	@failure		422	{object}	map[string]string "Unprocessable Entity" // e.g., Validation errors
-//	@failure		500	{object}	map[string]string "Internal Server Error"
-//	@router			/user-days/{id} [put]
-func (a *API) Update(w http.ResponseWriter, r *http.Request) {
-	enableCors(&w)
-	paramValue := chi.URLParam(r, "id")
-	id, err := uuid.Parse(paramValue)
-	if err != nil {
-		handleErr(w, http.StatusBadRequest, "Invalid ID format (must be UUID)", err)
-		return
-	}
-
-	form := &Form{}
-	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
-		handleErr(w, http.StatusBadRequest, "Invalid request body JSON", err)
-		return
-	}
-
-	// --- TODO: Add validation for nutritional values (e.g., >= 0) ---
-
-	// Create a model instance only with fields to be updated + ID for WHERE clause
-	userDayToUpdate := &UserDay{
-		ID:            id,
-		DailyKcal:     form.DailyKcal,
-		DailyProteins: form.DailyProteins,
-		DailyCarbs:    form.DailyCarbs,
-		DailyFats:     form.DailyFats,
-		// UserID and UserDate are NOT updated via this method
-	}
-
-	rowsAffected, err := a.repository.Update(userDayToUpdate)
+	dto, err := a.toDtoWithMeals(userDay)
 	if err != nil {
-		handleErr(w, http.StatusInternalServerError, "Failed to update user day", err)
+		handleErr(w, http.StatusInternalServerError, "Failed to compute meal subtotals and goal", err)
 		return
 	}
 
-	if rowsAffected == 0 {
-		// Could be not found, or data was identical. Assume not found for simplicity.
-		handleErr(w, http.StatusNotFound, "User day record not found or no changes detected", nil)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(dto); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode response", err)
 		return
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"message": "User day updated successfully"})
 }
 
 // Delete godoc
@@ -353,14 +292,12 @@ func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 //	@router			/user-days/{id} [delete]
 func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
 	enableCors(&w)
-	paramValue := chi.URLParam(r, "id")
-	id, err := uuid.Parse(paramValue)
-	if err != nil {
-		handleErr(w, http.StatusBadRequest, "Invalid ID format (must be UUID)", err)
+	userDay := a.readOwned(w, r)
+	if userDay == nil {
 		return
 	}
 
-	rowsAffected, err := a.repository.Delete(id)
+	rowsAffected, err := a.repository.Delete(userDay.ID)
 	if err != nil {
 		handleErr(w, http.StatusInternalServerError, "Failed to delete user day", err)
 		return
@@ -4,7 +4,11 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+
+	"fitapp-backend/api/resource/common/nutrient"
+	"fitapp-backend/api/resource/goal"
 	// Importuj model użytkownika, jeśli chcesz zdefiniować relację GORM
 	// "fitapp-backend/api/resource/user"
 )
@@ -16,12 +20,13 @@ type UserDay struct {
 	UpdatedAt time.Time
 	DeletedAt gorm.DeletedAt `gorm:"index"` // Indeks na DeletedAt jest często przydatny
 
-	UserID        uuid.UUID `gorm:"type:uuid;not null;index:idx_userday_user_date"` // Klucz obcy + część indeksu złożonego
-	UserDate      time.Time `gorm:"type:date;not null;index:idx_userday_user_date"` // Data + część indeksu złożonego
-	DailyKcal     int       `gorm:"not null"`
-	DailyProteins int       `gorm:"not null"`
-	DailyCarbs    int       `gorm:"not null"`
-	DailyFats     int       `gorm:"not null"`
+	UserID        uuid.UUID       `gorm:"type:uuid;not null;index:idx_userday_user_date"` // Klucz obcy + część indeksu złożonego
+	UserDate      time.Time       `gorm:"type:date;not null;index:idx_userday_user_date"` // Data + część indeksu złożonego
+	DailyKcal     decimal.Decimal `gorm:"type:numeric(10,2);not null"`                    // Totals are derived from the day's diary entries, read-only for clients
+	DailyProteins decimal.Decimal `gorm:"type:numeric(10,2);not null"`
+	DailyCarbs    decimal.Decimal `gorm:"type:numeric(10,2);not null"`
+	DailyFats     decimal.Decimal `gorm:"type:numeric(10,2);not null"`
+	Nutrients     nutrient.Set    `gorm:"type:jsonb;not null"` // extended nutrient totals
 
 	// Opcjonalna definicja relacji dla GORM (np. do Eager Loading)
 	// User          user.User `gorm:"foreignKey:UserID"`
@@ -32,23 +37,35 @@ type UserDays []*UserDay
 
 // DTO represents the data transfer object for a UserDay
 type DTO struct {
-	ID            string `json:"id"`
-	UserID        string `json:"user_id"`
-	UserDate      string `json:"user_date"` // Format "YYYY-MM-DD"
-	DailyKcal     int    `json:"daily_kcal"`
-	DailyProteins int    `json:"daily_proteins"`
-	DailyCarbs    int    `json:"daily_carbs"`
-	DailyFats     int    `json:"daily_fats"`
+	ID            string             `json:"id"`
+	UserID        string             `json:"user_id"`
+	UserDate      string             `json:"user_date"` // Format "YYYY-MM-DD"
+	DailyKcal     float64            `json:"daily_kcal"`
+	DailyProteins float64            `json:"daily_proteins"`
+	DailyCarbs    float64            `json:"daily_carbs"`
+	DailyFats     float64            `json:"daily_fats"`
+	Nutrients     map[string]float64 `json:"nutrients,omitempty"` // Extended nutrient totals keyed by code
+	Meals         []*MealTotalDTO    `json:"meals,omitempty"`     // Subtotals per meal slot
+	Target        *goal.TargetDTO    `json:"target,omitempty"`    // Goal in effect on this date
+	Remaining     *goal.TargetDTO    `json:"remaining,omitempty"` // Target minus the daily totals, negative when exceeded
 	// Można dodać CreatedAt/UpdatedAt w razie potrzeby
 }
 
-// Form represents the data structure for creating/updating a UserDay
-// Parsowanie stringów UserID i UserDate odbywa się w handlerze
-type Form struct {
-	UserID        string `json:"user_id"`   // Oczekiwany UUID jako string
-	UserDate      string `json:"user_date"` // Oczekiwana data w formacie "YYYY-MM-DD"
-	DailyKcal     int    `json:"daily_kcal"`
-	DailyProteins int    `json:"daily_proteins"`
-	DailyCarbs    int    `json:"daily_carbs"`
-	DailyFats     int    `json:"daily_fats"`
+// MealTotal holds the nutritional subtotal of a single meal within a day,
+// summed from the diary entries logged under that meal
+type MealTotal struct {
+	Meal     string
+	Kcal     decimal.Decimal
+	Proteins decimal.Decimal
+	Carbs    decimal.Decimal
+	Fats     decimal.Decimal
+}
+
+// MealTotalDTO represents a MealTotal rounded for the API
+type MealTotalDTO struct {
+	Meal     string  `json:"meal"`
+	Kcal     float64 `json:"kcal"`
+	Proteins float64 `json:"proteins"`
+	Carbs    float64 `json:"carbs"`
+	Fats     float64 `json:"fats"`
 }
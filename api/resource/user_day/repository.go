@@ -1,10 +1,15 @@
 package userday
 
 import (
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"fitapp-backend/api/resource/common/nutrient"
+	"fitapp-backend/api/resource/meal"
 )
 
 // Repository handles database operations for user_days
@@ -28,6 +33,15 @@ func (r *Repository) List() (UserDays, error) {
 	return userDays, nil
 }
 
+// ListByUser retrieves the non-deleted user_days of a single user ordered by date
+func (r *Repository) ListByUser(userID uuid.UUID) (UserDays, error) {
+	userDays := make([]*UserDay, 0)
+	if err := r.db.Where("user_id = ?", userID).Order("user_date").Find(&userDays).Error; err != nil {
+		return nil, err
+	}
+	return userDays, nil
+}
+
 // Create inserts a new user_day record into the database
 func (r *Repository) Create(userDay *UserDay) (*UserDay, error) {
 	if err := r.db.Create(userDay).Error; err != nil {
@@ -37,6 +51,29 @@ func (r *Repository) Create(userDay *UserDay) (*UserDay, error) {
 	return userDay, nil
 }
 
+// Lock creates the user's day if it does not exist yet and locks its row until
+// the end of the transaction r runs in, serializing writes to the same day
+func (r *Repository) Lock(userID uuid.UUID, date time.Time) (*UserDay, error) {
+	empty := &UserDay{ID: uuid.New(), UserID: userID, UserDate: date, Nutrients: nutrient.Set{}}
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "user_id"}, {Name: "user_date"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_at IS NULL"}}},
+		DoNothing:   true,
+	}).Create(empty).Error
+	if err != nil {
+		return nil, err
+	}
+
+	userDay := &UserDay{}
+	err = r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND user_date = ?", userID, date.Format(DateFormat)).
+		First(userDay).Error
+	if err != nil {
+		return nil, err
+	}
+	return userDay, nil
+}
+
 // Read retrieves a single user_day record by its primary ID
 func (r *Repository) Read(id uuid.UUID) (*UserDay, error) {
 	userDay := &UserDay{}
@@ -57,12 +94,36 @@ func (r *Repository) FindByUserAndDate(userID uuid.UUID, date time.Time) (*UserD
 	return userDay, nil
 }
 
+// MealTotals sums the diary entries of a user's day grouped by meal,
+// ordered with the standard meal slots first
+func (r *Repository) MealTotals(userID uuid.UUID, date time.Time) ([]*MealTotal, error) {
+	totals := make([]*MealTotal, 0)
+	dateStr := date.Format(DateFormat)
+	err := r.db.Table("diary_entries").
+		Select("meal, SUM(kcal) AS kcal, SUM(proteins) AS proteins, SUM(carbs) AS carbs, SUM(fats) AS fats").
+		Where("user_id = ? AND user_date = ? AND deleted_at IS NULL", userID, dateStr).
+		Group("meal").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(totals, func(i, j int) bool {
+		ri, rj := meal.Rank(totals[i].Meal), meal.Rank(totals[j].Meal)
+		if ri != rj {
+			return ri < rj
+		}
+		return totals[i].Meal < totals[j].Meal
+	})
+	return totals, nil
+}
+
 // Update modifies an existing user_day record in the database.
 // Only updates nutritional values and UpdatedAt timestamp.
 func (r *Repository) Update(userDay *UserDay) (int64, error) {
 	result := r.db.Model(&UserDay{}).
 		// Select only the fields allowed to be updated
-		Select("DailyKcal", "DailyProteins", "DailyCarbs", "DailyFats", "UpdatedAt").
+		Select("DailyKcal", "DailyProteins", "DailyCarbs", "DailyFats", "Nutrients", "UpdatedAt").
 		Where("id = ?", userDay.ID).
 		Updates(userDay) // Pass the userDay struct with new values
 
@@ -1,15 +1,17 @@
 package userday_test
 
 import (
-	"fitapp-backend/api/resource/userday" // Adjust import path
-	mockDB "fitapp-backend/mock/db"       // Adjust import path
-	testUtil "fitapp-backend/util/test"   // Adjust import path
+	"fitapp-backend/api/resource/common/nutrient"
+	"fitapp-backend/api/resource/user_day" // Adjust import path
+	mockDB "fitapp-backend/mock/db"        // Adjust import path
+	testUtil "fitapp-backend/util/test"    // Adjust import path
 	"regexp"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
@@ -48,6 +50,28 @@ func TestRepository_List(t *testing.T) {
 	testUtil.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestRepository_ListByUser(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := userday.NewRepository(db)
+
+	testDate := getTestDate(t)
+	userID := uuid.New()
+
+	mockRows := sqlmock.NewRows(userDayColumns).
+		AddRow(uuid.New(), time.Now(), time.Now(), gorm.DeletedAt{}, userID, testDate, 2000, 150, 200, 80)
+
+	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "user_days" WHERE user_id = $1 AND "user_days"."deleted_at" IS NULL ORDER BY user_date`)
+	mock.ExpectQuery(expectedSQL).WithArgs(userID).WillReturnRows(mockRows)
+
+	userDays, err := repo.ListByUser(userID)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 1, len(userDays))
+	testUtil.Equal(t, userID, userDays[0].UserID)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestRepository_Create(t *testing.T) {
 	t.Parallel()
 	db, mock, err := mockDB.NewMockDB()
@@ -61,15 +85,16 @@ func TestRepository_Create(t *testing.T) {
 		ID:            id,
 		UserID:        userID,
 		UserDate:      testDate,
-		DailyKcal:     2200,
-		DailyProteins: 160,
-		DailyCarbs:    210,
-		DailyFats:     90,
+		DailyKcal:     decimal.NewFromInt(2200),
+		DailyProteins: decimal.NewFromInt(160),
+		DailyCarbs:    decimal.NewFromInt(210),
+		DailyFats:     decimal.NewFromInt(90),
+		Nutrients:     nutrient.Set{nutrient.Sodium: decimal.NewFromInt(1800)},
 	}
 
 	mock.ExpectBegin()
 	// Adjust columns based on GORM behavior - it might omit zero values unless specified
-	expectedSQL := regexp.QuoteMeta(`INSERT INTO "user_days" ("id","created_at","updated_at","deleted_at","user_id","user_date","daily_kcal","daily_proteins","daily_carbs","daily_fats") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)`)
+	expectedSQL := regexp.QuoteMeta(`INSERT INTO "user_days" ("id","created_at","updated_at","deleted_at","user_id","user_date","daily_kcal","daily_proteins","daily_carbs","daily_fats","nutrients") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)`)
 	mock.ExpectExec(expectedSQL).
 		WithArgs(
 			newUserDay.ID,
@@ -82,6 +107,7 @@ func TestRepository_Create(t *testing.T) {
 			newUserDay.DailyProteins,
 			newUserDay.DailyCarbs,
 			newUserDay.DailyFats,
+			`{"sodium":"1800"}`,
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
@@ -102,19 +128,19 @@ func TestRepository_Read(t *testing.T) {
 	id := uuid.New()
 	userID := uuid.New()
 	testDate := getTestDate(t)
-	expectedKcal := 2100
+	expectedKcal := "2100.5"
 
 	mockRows := sqlmock.NewRows(userDayColumns).
 		AddRow(id, time.Now(), time.Now(), gorm.DeletedAt{}, userID, testDate, expectedKcal, 155, 205, 85)
 
-	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "user_days" WHERE id = $1 AND "user_days"."deleted_at" IS NULL ORDER BY "user_days"."id" LIMIT 1`)
-	mock.ExpectQuery(expectedSQL).WithArgs(id).WillReturnRows(mockRows)
+	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "user_days" WHERE id = $1 AND "user_days"."deleted_at" IS NULL ORDER BY "user_days"."id" LIMIT $2`)
+	mock.ExpectQuery(expectedSQL).WithArgs(id, 1).WillReturnRows(mockRows)
 
 	found, err := repo.Read(id)
 	testUtil.NoError(t, err)
 	testUtil.NotNil(t, found)
 	testUtil.Equal(t, id, found.ID)
-	testUtil.Equal(t, expectedKcal, found.DailyKcal)
+	testUtil.Equal(t, expectedKcal, found.DailyKcal.String())
 	testUtil.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -125,8 +151,8 @@ func TestRepository_Read_NotFound(t *testing.T) {
 	repo := userday.NewRepository(db)
 	id := uuid.New()
 
-	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "user_days" WHERE id = $1 AND "user_days"."deleted_at" IS NULL ORDER BY "user_days"."id" LIMIT 1`)
-	mock.ExpectQuery(expectedSQL).WithArgs(id).WillReturnError(gorm.ErrRecordNotFound)
+	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "user_days" WHERE id = $1 AND "user_days"."deleted_at" IS NULL ORDER BY "user_days"."id" LIMIT $2`)
+	mock.ExpectQuery(expectedSQL).WithArgs(id, 1).WillReturnError(gorm.ErrRecordNotFound)
 
 	_, err = repo.Read(id)
 	testUtil.ErrorIs(t, err, gorm.ErrRecordNotFound)
@@ -143,21 +169,21 @@ func TestRepository_FindByUserAndDate(t *testing.T) {
 	userID := uuid.New()
 	testDate := getTestDate(t)
 	dateStr := testDate.Format(userday.DateFormat)
-	expectedKcal := 2300
+	expectedKcal := "2300"
 
 	mockRows := sqlmock.NewRows(userDayColumns).
 		AddRow(id, time.Now(), time.Now(), gorm.DeletedAt{}, userID, testDate, expectedKcal, 170, 220, 95)
 
 	// Note: Query uses user_id and user_date in WHERE clause
-	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "user_days" WHERE user_id = $1 AND user_date = $2 AND "user_days"."deleted_at" IS NULL ORDER BY "user_days"."id" LIMIT 1`)
-	mock.ExpectQuery(expectedSQL).WithArgs(userID, dateStr).WillReturnRows(mockRows)
+	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "user_days" WHERE (user_id = $1 AND user_date = $2) AND "user_days"."deleted_at" IS NULL ORDER BY "user_days"."id" LIMIT $3`)
+	mock.ExpectQuery(expectedSQL).WithArgs(userID, dateStr, 1).WillReturnRows(mockRows)
 
 	found, err := repo.FindByUserAndDate(userID, testDate)
 	testUtil.NoError(t, err)
 	testUtil.NotNil(t, found)
 	testUtil.Equal(t, id, found.ID) // Should find the correct primary ID
 	testUtil.Equal(t, userID, found.UserID)
-	testUtil.Equal(t, expectedKcal, found.DailyKcal)
+	testUtil.Equal(t, expectedKcal, found.DailyKcal.String())
 	testUtil.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -171,8 +197,8 @@ func TestRepository_FindByUserAndDate_NotFound(t *testing.T) {
 	testDate := getTestDate(t)
 	dateStr := testDate.Format(userday.DateFormat)
 
-	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "user_days" WHERE user_id = $1 AND user_date = $2 AND "user_days"."deleted_at" IS NULL ORDER BY "user_days"."id" LIMIT 1`)
-	mock.ExpectQuery(expectedSQL).WithArgs(userID, dateStr).WillReturnError(gorm.ErrRecordNotFound)
+	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "user_days" WHERE (user_id = $1 AND user_date = $2) AND "user_days"."deleted_at" IS NULL ORDER BY "user_days"."id" LIMIT $3`)
+	mock.ExpectQuery(expectedSQL).WithArgs(userID, dateStr, 1).WillReturnError(gorm.ErrRecordNotFound)
 
 	_, err = repo.FindByUserAndDate(userID, testDate)
 	testUtil.ErrorIs(t, err, gorm.ErrRecordNotFound)
@@ -188,24 +214,25 @@ func TestRepository_Update(t *testing.T) {
 	id := uuid.New()
 	userDayToUpdate := &userday.UserDay{
 		ID:            id, // ID is crucial for WHERE clause
-		DailyKcal:     2500,
-		DailyProteins: 190,
-		DailyCarbs:    260,
-		DailyFats:     110,
+		DailyKcal:     decimal.NewFromInt(2500),
+		DailyProteins: decimal.NewFromInt(190),
+		DailyCarbs:    decimal.NewFromInt(260),
+		DailyFats:     decimal.NewFromInt(110),
 		// UserID and UserDate should not be included in the SET clause per repository logic
 	}
 
 	mock.ExpectBegin()
 	// Update should only set selected fields + UpdatedAt
-	expectedSQL := regexp.QuoteMeta(`UPDATE "user_days" SET "daily_kcal"=$1,"daily_proteins"=$2,"daily_carbs"=$3,"daily_fats"=$4,"updated_at"=$5 WHERE id = $6`)
+	expectedSQL := regexp.QuoteMeta(`UPDATE "user_days" SET "updated_at"=$1,"daily_kcal"=$2,"daily_proteins"=$3,"daily_carbs"=$4,"daily_fats"=$5,"nutrients"=$6 WHERE id = $7 AND "user_days"."deleted_at" IS NULL`)
 	mock.ExpectExec(expectedSQL).
 		WithArgs(
+			mockDB.AnyTime{}, // UpdatedAt
 			userDayToUpdate.DailyKcal,
 			userDayToUpdate.DailyProteins,
 			userDayToUpdate.DailyCarbs,
 			userDayToUpdate.DailyFats,
-			mockDB.AnyTime{}, // UpdatedAt
-			id,               // WHERE id = ?
+			"{}", // Nutrients
+			id,   // WHERE id = ?
 		).
 		WillReturnResult(sqlmock.NewResult(0, 1)) // 1 row affected
 	mock.ExpectCommit()
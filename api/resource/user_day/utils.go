@@ -1,17 +1,47 @@
 package userday
 
+import (
+	"errors"
+	"fmt"
+
+	"fitapp-backend/api/resource/common/nutrient"
+)
+
 const DateFormat = "2006-01-02" // Standardowy format daty Go dla YYYY-MM-DD
 
+// Today may be used instead of a date; it resolves to the current date in the user's timezone
+const Today = "today"
+
+// ErrInvalidDate is returned by ParseDate for values that are neither a date nor Today
+var ErrInvalidDate = fmt.Errorf("invalid date format (must be %s or %q)", DateFormat, Today)
+
+// invalidDate wraps a date parsing error as ErrInvalidDate
+func invalidDate(err error) error {
+	return errors.Join(ErrInvalidDate, err)
+}
+
 // ToDto converts a UserDay model to its DTO representation
 func (ud *UserDay) ToDto() *DTO {
 	return &DTO{
 		ID:            ud.ID.String(),
 		UserID:        ud.UserID.String(),
 		UserDate:      ud.UserDate.Format(DateFormat), // Formatuj datę
-		DailyKcal:     ud.DailyKcal,
-		DailyProteins: ud.DailyProteins,
-		DailyCarbs:    ud.DailyCarbs,
-		DailyFats:     ud.DailyFats,
+		DailyKcal:     nutrient.Kcal(ud.DailyKcal),
+		DailyProteins: nutrient.Macro(ud.DailyProteins),
+		DailyCarbs:    nutrient.Macro(ud.DailyCarbs),
+		DailyFats:     nutrient.Macro(ud.DailyFats),
+		Nutrients:     ud.Nutrients.ToDto(),
+	}
+}
+
+// ToDto converts a MealTotal to its DTO representation
+func (mt *MealTotal) ToDto() *MealTotalDTO {
+	return &MealTotalDTO{
+		Meal:     mt.Meal,
+		Kcal:     nutrient.Kcal(mt.Kcal),
+		Proteins: nutrient.Macro(mt.Proteins),
+		Carbs:    nutrient.Macro(mt.Carbs),
+		Fats:     nutrient.Macro(mt.Fats),
 	}
 }
 
@@ -0,0 +1,471 @@
+package weight
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"fitapp-backend/api/resource/common/energy"
+	"fitapp-backend/api/resource/goal"
+	"fitapp-backend/api/resource/user"
+)
+
+// API holds the dependencies for the weight entry handlers
+type API struct {
+	repository     *Repository
+	userRepository *user.Repository
+	goalRepository *goal.Repository
+}
+
+// New creates a new API instance for weight entry routes
+func New(db *gorm.DB) *API {
+	return &API{
+		repository:     NewRepository(db),
+		userRepository: user.NewRepository(db),
+		goalRepository: goal.NewRepository(db),
+	}
+}
+
+func handleErr(w http.ResponseWriter, status int, message string, err error) {
+	// Log the error internally
+	fmt.Printf("ERROR [%d]: %s - %v\n", status, message, err)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+func enableCors(w *http.ResponseWriter) {
+	(*w).Header().Set("Access-Control-Allow-Origin", "*")
+}
+
+// parseUserID reads the user ID path parameter
+func parseUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid user ID format (must be UUID)", err)
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// parseEntryID reads the entry ID path parameter
+func parseEntryID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "entryId"))
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid entry ID format (must be UUID)", err)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// parseDate parses an optional date query or form value.
+// Returns false if an error response has been written.
+func parseDate(w http.ResponseWriter, field string, value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, true
+	}
+	date, err := time.Parse(DateFormat, value)
+	if err != nil {
+		handleErr(w, http.StatusBadRequest, fmt.Sprintf("Invalid %s format (must be %s)", field, DateFormat), err)
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// fillFromForm validates the form and applies it to the entry, defaulting the
+// date to the user's today. Returns false if an error response has been written.
+func (a *API) fillFromForm(w http.ResponseWriter, form *Form, entry *Entry) bool {
+	if err := form.Validate(); err != nil {
+		handleErr(w, http.StatusUnprocessableEntity, err.Error(), err)
+		return false
+	}
+	date, ok := parseDate(w, "date", form.Date)
+	if !ok {
+		return false
+	}
+	if date.IsZero() {
+		u, err := a.userRepository.Read(entry.UserID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				handleErr(w, http.StatusNotFound, "User not found", err)
+			} else {
+				handleErr(w, http.StatusInternalServerError, "Failed to read user", err)
+			}
+			return false
+		}
+		date = u.Today()
+	}
+
+	exists, err := a.repository.ExistsOnDate(entry.UserID, date, entry.ID)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to check existing weight entries", err)
+		return false
+	}
+	if exists {
+		handleErr(w, http.StatusUnprocessableEntity, "A weight entry for this date already exists", nil)
+		return false
+	}
+
+	entry.EntryDate = date
+	entry.WeightKg = form.WeightKg
+	entry.Note = form.Note
+	return true
+}
+
+// List godoc
+//
+//	@summary		List weight entries
+//	@description	List the user's body weight entries ordered by date, optionally within a date range
+//	@tags			weight
+//	@accept			json
+//	@produce		json
+//	@param			id		path		string	true	"User ID (UUID)"
+//	@param			from	query		string	false	"First date (YYYY-MM-DD)"
+//	@param			to		query		string	false	"Last date (YYYY-MM-DD)"
+//	@success		200	{array}		DTO
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/weights [get]
+func (a *API) List(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	userID, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+	from, ok := parseDate(w, "from", r.URL.Query().Get("from"))
+	if !ok {
+		return
+	}
+	to, ok := parseDate(w, "to", r.URL.Query().Get("to"))
+	if !ok {
+		return
+	}
+
+	entries, err := a.repository.List(userID, from, to)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to retrieve weight entries", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(entries.ToDto()); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode response", err)
+		return
+	}
+}
+
+// Create godoc
+//
+//	@summary		Create weight entry
+//	@description	Log the user's body weight for a date (default: today in the user's timezone).
+//	@description	The user's current weight follows the latest entry.
+//	@tags			weight
+//	@accept			json
+//	@produce		json
+//	@param			id		path	string	true	"User ID (UUID)"
+//	@param			body	body	Form	true	"Weight entry form"
+//	@success		201	{object}	DTO "Returns the created entry"
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		422	{object}	map[string]string "Unprocessable Entity"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/weights [post]
+func (a *API) Create(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	userID, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	form := &Form{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid request body JSON", err)
+		return
+	}
+
+	newEntry := &Entry{
+		ID:     uuid.New(),
+		UserID: userID,
+	}
+	if !a.fillFromForm(w, form, newEntry) {
+		return
+	}
+
+	createdEntry, err := a.repository.Create(newEntry)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to create weight entry", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(createdEntry.ToDto()); err != nil {
+		fmt.Printf("Error encoding created weight entry response: %v\n", err)
+	}
+}
+
+// Read godoc
+//
+//	@summary		Read weight entry
+//	@description	Read a single body weight entry of the user
+//	@tags			weight
+//	@accept			json
+//	@produce		json
+//	@param			id		path		string	true	"User ID (UUID)"
+//	@param			entryId	path		string	true	"Entry ID (UUID)"
+//	@success		200	{object}	DTO
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/weights/{entryId} [get]
+func (a *API) Read(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	userID, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+	id, ok := parseEntryID(w, r)
+	if !ok {
+		return
+	}
+
+	entry, err := a.repository.Read(userID, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			handleErr(w, http.StatusNotFound, "Weight entry not found", err)
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to read weight entry", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(entry.ToDto()); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode response", err)
+		return
+	}
+}
+
+// Update godoc
+//
+//	@summary		Update weight entry
+//	@description	Change the date, weight or note of an entry. The user's current weight follows the latest entry.
+//	@tags			weight
+//	@accept			json
+//	@produce		json
+//	@param			id		path		string	true	"User ID (UUID)"
+//	@param			entryId	path		string	true	"Entry ID (UUID)"
+//	@param			body	body		Form	true	"Weight entry form"
+//	@success		200	{object}	DTO "Returns the updated entry"
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		422	{object}	map[string]string "Unprocessable Entity"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/weights/{entryId} [put]
+func (a *API) Update(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	userID, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+	id, ok := parseEntryID(w, r)
+	if !ok {
+		return
+	}
+
+	form := &Form{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		handleErr(w, http.StatusBadRequest, "Invalid request body JSON", err)
+		return
+	}
+
+	entry, err := a.repository.Read(userID, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			handleErr(w, http.StatusNotFound, "Weight entry not found", err)
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to read weight entry", err)
+		}
+		return
+	}
+	if form.Date == "" {
+		form.Date = entry.EntryDate.Format(DateFormat) // Keep the date unless it is changed
+	}
+	if !a.fillFromForm(w, form, entry) {
+		return
+	}
+
+	if _, err := a.repository.Update(entry); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to update weight entry", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(entry.ToDto())
+}
+
+// Delete godoc
+//
+//	@summary		Delete weight entry
+//	@description	Soft delete a weight entry. The user's current weight follows the remaining latest entry.
+//	@tags			weight
+//	@accept			json
+//	@produce		json
+//	@param			id		path	string	true	"User ID (UUID)"
+//	@param			entryId	path	string	true	"Entry ID (UUID)"
+//	@success		200	{object}	map[string]string "Successfully deleted"
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/weights/{entryId} [delete]
+func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	userID, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+	id, ok := parseEntryID(w, r)
+	if !ok {
+		return
+	}
+
+	rowsAffected, err := a.repository.Delete(userID, id)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to delete weight entry", err)
+		return
+	}
+	if rowsAffected == 0 {
+		handleErr(w, http.StatusNotFound, "Weight entry not found or already deleted", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Weight entry deleted successfully"})
+}
+
+// Trend godoc
+//
+//	@summary		Weight trend and projection
+//	@description	Smooth the user's weight entries of the last window days with an exponential moving average,
+//	@description	derive the weekly rate of change (from the entries, or from the average logged calories
+//	@description	against estimated maintenance calories when there are too few entries) and project the date
+//	@description	the target weight of the current goal is reached.
+//	@tags			weight
+//	@accept			json
+//	@produce		json
+//	@param			id		path		string	true	"User ID (UUID)"
+//	@param			window	query		int		false	"Days to analyse (default 30, 7-365)"
+//	@success		200	{object}	TrendDTO
+//	@failure		400	{object}	map[string]string "Bad Request"
+//	@failure		404	{object}	map[string]string "Not Found"
+//	@failure		500	{object}	map[string]string "Internal Server Error"
+//	@router			/users/{id}/weight/trend [get]
+func (a *API) Trend(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	userID, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	window := DefaultTrendWindow
+	if v := r.URL.Query().Get("window"); v != "" {
+		var err error
+		window, err = strconv.Atoi(v)
+		if err != nil || window < MinTrendWindow || window > MaxTrendWindow {
+			handleErr(w, http.StatusBadRequest, fmt.Sprintf("window must be between %d and %d days", MinTrendWindow, MaxTrendWindow), err)
+			return
+		}
+	}
+
+	u, err := a.userRepository.Read(userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			handleErr(w, http.StatusNotFound, "User not found", err)
+		} else {
+			handleErr(w, http.StatusInternalServerError, "Failed to read user", err)
+		}
+		return
+	}
+	today := u.Today()
+	from := today.AddDate(0, 0, 1-window)
+
+	entries, err := a.repository.List(userID, from, today)
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to retrieve weight entries", err)
+		return
+	}
+	// Today is still being logged, so intake is averaged over the completed days
+	intake, err := a.repository.AverageIntake(userID, from, today.AddDate(0, 0, -1))
+	if err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to compute average intake", err)
+		return
+	}
+
+	points := Smooth(entries)
+	dto := &TrendDTO{
+		WindowDays: window,
+		Points:     make([]*TrendPointDTO, len(points)),
+		LoggedDays: intake.Days,
+	}
+	for i, p := range points {
+		dto.Points[i] = p.ToDto()
+	}
+	if intake.Days > 0 {
+		dto.AverageKcal = roundPtr(intake.AverageKcal, 0)
+	}
+
+	current := float64(u.Weight)
+	if len(points) > 0 {
+		current = points[len(points)-1].TrendKg
+		dto.CurrentTrendKg = roundPtr(current, 2)
+	}
+
+	kgPerDay, hasRate := Rate(points)
+	if hasRate {
+		dto.RateSource = RateFromTrend
+		if intake.Days > 0 {
+			dto.EstimatedTDEE = roundPtr(intake.AverageKcal-kgPerDay*KcalPerKg, 0)
+		}
+	} else if intake.Days > 0 {
+		maintenance, err := u.Energy(energy.MifflinStJeor, 0)
+		if err != nil {
+			handleErr(w, http.StatusInternalServerError, "Failed to estimate energy expenditure", err)
+			return
+		}
+		kgPerDay = (intake.AverageKcal - float64(maintenance.MaintenanceKcal)) / KcalPerKg
+		hasRate = true
+		dto.RateSource = RateFromIntake
+	}
+	if hasRate {
+		dto.WeeklyChangeKg = roundPtr(kgPerDay*7, 2)
+	}
+
+	g, err := a.goalRepository.InEffect(userID, today)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		handleErr(w, http.StatusInternalServerError, "Failed to read goal", err)
+		return
+	}
+	if g != nil && g.TargetWeightKg.Valid {
+		target := g.TargetWeightKg.Decimal.InexactFloat64()
+		dto.TargetWeightKg = &target
+		if hasRate {
+			if date, ok := Project(today, current, target, kgPerDay); ok {
+				dto.ProjectedDate = date.Format(DateFormat)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(dto); err != nil {
+		handleErr(w, http.StatusInternalServerError, "Failed to encode response", err)
+		return
+	}
+}
@@ -0,0 +1,90 @@
+package weight
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Limits of accepted entries
+var (
+	MinWeightKg   = decimal.NewFromInt(20)
+	MaxWeightKg   = decimal.NewFromInt(500)
+	MaxNoteLength = 500
+)
+
+// Entry represents a body weight measurement in the 'weight_entries' table.
+// A user has at most one entry per date.
+type Entry struct {
+	ID        uuid.UUID `gorm:"type:uuid;primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	UserID    uuid.UUID       `gorm:"type:uuid;not null"`
+	EntryDate time.Time       `gorm:"type:date;not null"`
+	WeightKg  decimal.Decimal `gorm:"type:numeric(5,2);not null"`
+	Note      string          `gorm:"not null"`
+}
+
+// TableName overrides the inferred 'entries' table name
+func (Entry) TableName() string {
+	return "weight_entries"
+}
+
+// Entries is a slice of Entry pointers
+type Entries []*Entry
+
+// DTO represents the data transfer object for an Entry
+type DTO struct {
+	ID       string  `json:"id"`
+	UserID   string  `json:"user_id"`
+	Date     string  `json:"date"` // Format "YYYY-MM-DD"
+	WeightKg float64 `json:"weight_kg"`
+	Note     string  `json:"note,omitempty"`
+}
+
+// Form represents the data structure for creating/updating an Entry.
+// UserID comes from the URL path.
+type Form struct {
+	Date     string          `json:"date"` // Optional, "YYYY-MM-DD"; defaults to the user's today
+	WeightKg decimal.Decimal `json:"weight_kg"`
+	Note     string          `json:"note"`
+}
+
+// TrendPoint is a measurement with the smoothed weight up to its date
+type TrendPoint struct {
+	Date     time.Time
+	WeightKg float64
+	TrendKg  float64
+}
+
+// Intake summarizes the calories logged in user days over a period
+type Intake struct {
+	AverageKcal float64 // Per logged day
+	Days        int     // Days with any calories logged
+}
+
+// TrendPointDTO represents a TrendPoint
+type TrendPointDTO struct {
+	Date     string  `json:"date"` // Format "YYYY-MM-DD"
+	WeightKg float64 `json:"weight_kg"`
+	TrendKg  float64 `json:"trend_kg"`
+}
+
+// TrendDTO represents the smoothed weight trend of a period and its projection.
+// Optional values are omitted when there is not enough data.
+type TrendDTO struct {
+	WindowDays     int              `json:"window_days"`
+	Points         []*TrendPointDTO `json:"points"`
+	CurrentTrendKg *float64         `json:"current_trend_kg,omitempty"`
+	WeeklyChangeKg *float64         `json:"weekly_change_kg,omitempty"`
+	RateSource     string           `json:"rate_source,omitempty"` // "weight_trend" or "energy_balance"
+	AverageKcal    *float64         `json:"average_kcal,omitempty"`
+	LoggedDays     int              `json:"logged_days"`
+	EstimatedTDEE  *float64         `json:"estimated_tdee,omitempty"` // Maintenance calories implied by intake and trend
+	TargetWeightKg *float64         `json:"target_weight_kg,omitempty"`
+	ProjectedDate  string           `json:"projected_date,omitempty"` // Format "YYYY-MM-DD"
+}
@@ -0,0 +1,133 @@
+package weight
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"fitapp-backend/api/resource/user"
+)
+
+// Repository handles database operations for weight_entries
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new weight entry repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// List retrieves a user's entries between from and to (inclusive, either may
+// be zero for an open range) ordered by date
+func (r *Repository) List(userID uuid.UUID, from, to time.Time) (Entries, error) {
+	entries := make([]*Entry, 0)
+	query := r.db.Where("user_id = ?", userID)
+	if !from.IsZero() {
+		query = query.Where("entry_date >= ?", from.Format(DateFormat))
+	}
+	if !to.IsZero() {
+		query = query.Where("entry_date <= ?", to.Format(DateFormat))
+	}
+	if err := query.Order("entry_date").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Read retrieves a single entry of the user by its ID
+func (r *Repository) Read(userID uuid.UUID, id uuid.UUID) (*Entry, error) {
+	entry := &Entry{}
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(entry).Error; err != nil {
+		return nil, err // Can be gorm.ErrRecordNotFound
+	}
+	return entry, nil
+}
+
+// ExistsOnDate reports whether the user has an entry other than excludeID on the date
+func (r *Repository) ExistsOnDate(userID uuid.UUID, date time.Time, excludeID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&Entry{}).
+		Where("user_id = ? AND entry_date = ? AND id <> ?", userID, date.Format(DateFormat), excludeID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Create inserts a new entry and updates the user's current weight
+func (r *Repository) Create(entry *Entry) (*Entry, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		return syncUserWeight(tx, entry.UserID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Update modifies the date, weight and note of an entry and updates the user's current weight
+func (r *Repository) Update(entry *Entry) (int64, error) {
+	var rowsAffected int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Entry{}).
+			Select("EntryDate", "WeightKg", "Note", "UpdatedAt").
+			Where("id = ? AND user_id = ?", entry.ID, entry.UserID).
+			Updates(entry)
+		if result.Error != nil {
+			return result.Error
+		}
+		rowsAffected = result.RowsAffected
+		return syncUserWeight(tx, entry.UserID)
+	})
+	return rowsAffected, err
+}
+
+// Delete performs a soft delete on an entry and updates the user's current weight
+func (r *Repository) Delete(userID uuid.UUID, id uuid.UUID) (int64, error) {
+	var rowsAffected int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", id, userID).Delete(&Entry{})
+		if result.Error != nil {
+			return result.Error
+		}
+		rowsAffected = result.RowsAffected
+		return syncUserWeight(tx, userID)
+	})
+	return rowsAffected, err
+}
+
+// AverageIntake averages the calories of the user's days between from and to
+// (inclusive), ignoring days without any logged calories
+func (r *Repository) AverageIntake(userID uuid.UUID, from, to time.Time) (*Intake, error) {
+	intake := &Intake{}
+	err := r.db.Table("user_days").
+		Select("COALESCE(AVG(daily_kcal), 0) AS average_kcal, COUNT(*) AS days").
+		Where("user_id = ? AND user_date BETWEEN ? AND ? AND daily_kcal > 0 AND deleted_at IS NULL",
+			userID, from.Format(DateFormat), to.Format(DateFormat)).
+		Scan(intake).Error
+	if err != nil {
+		return nil, err
+	}
+	return intake, nil
+}
+
+// syncUserWeight sets the user's current weight to their latest entry, rounded
+// to whole kilograms. Users without entries keep their profile weight.
+func syncUserWeight(tx *gorm.DB, userID uuid.UUID) error {
+	latest := tx.Model(&Entry{}).
+		Select("ROUND(weight_kg)").
+		Where("user_id = ?", userID).
+		Order("entry_date DESC").
+		Limit(1)
+	return tx.Model(&user.User{}).
+		Where("id = ? AND EXISTS (?)", userID, latest).
+		Update("user_weight", latest).Error
+}
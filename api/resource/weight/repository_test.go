@@ -0,0 +1,175 @@
+package weight_test
+
+import (
+	"math"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"fitapp-backend/api/resource/weight"
+	mockDB "fitapp-backend/mock/db"
+	testUtil "fitapp-backend/util/test"
+)
+
+var entryColumns = []string{"id", "created_at", "updated_at", "deleted_at", "user_id", "entry_date", "weight_kg", "note"}
+
+// syncSQL is the statement keeping users.user_weight in sync with the latest entry
+var syncSQL = regexp.QuoteMeta(`UPDATE "users" SET "user_weight"=(SELECT ROUND(weight_kg) FROM "weight_entries" WHERE user_id = $1 AND "weight_entries"."deleted_at" IS NULL ORDER BY entry_date DESC LIMIT $2),"updated_at"=$3 WHERE (id = $4 AND EXISTS (SELECT ROUND(weight_kg) FROM "weight_entries" WHERE user_id = $5 AND "weight_entries"."deleted_at" IS NULL ORDER BY entry_date DESC LIMIT $6)) AND "users"."deleted_at" IS NULL`)
+
+func TestRepository_List(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := weight.NewRepository(db)
+
+	userID := uuid.New()
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	mockRows := sqlmock.NewRows(entryColumns).
+		AddRow(uuid.New(), time.Now(), time.Now(), nil, userID, from, "82.40", "").
+		AddRow(uuid.New(), time.Now(), time.Now(), nil, userID, from.AddDate(0, 0, 1), "82.15", "after vacation")
+
+	expectedSQL := regexp.QuoteMeta(`SELECT * FROM "weight_entries" WHERE user_id = $1 AND entry_date >= $2 AND "weight_entries"."deleted_at" IS NULL ORDER BY entry_date`)
+	mock.ExpectQuery(expectedSQL).WithArgs(userID, "2024-03-01").WillReturnRows(mockRows)
+
+	entries, err := repo.List(userID, from, time.Time{})
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 2, len(entries))
+	dtos := entries.ToDto()
+	testUtil.Equal(t, "2024-03-02", dtos[1].Date)
+	testUtil.Equal(t, 82.15, dtos[1].WeightKg)
+	testUtil.Equal(t, "after vacation", dtos[1].Note)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Create(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := weight.NewRepository(db)
+
+	entry := &weight.Entry{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		EntryDate: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC),
+		WeightKg:  decimal.RequireFromString("81.7"),
+	}
+
+	mock.ExpectBegin()
+	expectedSQL := regexp.QuoteMeta(`INSERT INTO "weight_entries" ("id","created_at","updated_at","deleted_at","user_id","entry_date","weight_kg","note") VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`)
+	mock.ExpectExec(expectedSQL).
+		WithArgs(entry.ID, mockDB.AnyTime{}, mockDB.AnyTime{}, nil, entry.UserID, entry.EntryDate, "81.7", "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(syncSQL).
+		WithArgs(entry.UserID, 1, mockDB.AnyTime{}, entry.UserID, entry.UserID, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	created, err := repo.Create(entry)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, entry.ID, created.ID)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Delete(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := weight.NewRepository(db)
+
+	id, userID := uuid.New(), uuid.New()
+	mock.ExpectBegin()
+	expectedSQL := regexp.QuoteMeta(`UPDATE "weight_entries" SET "deleted_at"=$1 WHERE (id = $2 AND user_id = $3) AND "weight_entries"."deleted_at" IS NULL`)
+	mock.ExpectExec(expectedSQL).WithArgs(mockDB.AnyTime{}, id, userID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(syncSQL).
+		WithArgs(userID, 1, mockDB.AnyTime{}, userID, userID, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	rowsAffected, err := repo.Delete(userID, id)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, int64(1), rowsAffected)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_ExistsOnDate(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := weight.NewRepository(db)
+
+	userID, id := uuid.New(), uuid.New()
+	expectedSQL := regexp.QuoteMeta(`SELECT count(*) FROM "weight_entries" WHERE (user_id = $1 AND entry_date = $2 AND id <> $3) AND "weight_entries"."deleted_at" IS NULL`)
+	mock.ExpectQuery(expectedSQL).WithArgs(userID, "2024-03-15", id).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	exists, err := repo.ExistsOnDate(userID, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), id)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, false, exists)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestForm_Validate(t *testing.T) {
+	t.Parallel()
+	form := &weight.Form{WeightKg: decimal.RequireFromString("19.9")}
+	testUtil.Equal(t, true, form.Validate() != nil)
+
+	form = &weight.Form{WeightKg: decimal.RequireFromString("75.3"), Note: "  morning  "}
+	testUtil.NoError(t, form.Validate())
+	testUtil.Equal(t, "morning", form.Note)
+}
+
+func TestRepository_AverageIntake(t *testing.T) {
+	t.Parallel()
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+	repo := weight.NewRepository(db)
+
+	userID := uuid.New()
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	expectedSQL := regexp.QuoteMeta(`SELECT COALESCE(AVG(daily_kcal), 0) AS average_kcal, COUNT(*) AS days FROM "user_days" WHERE user_id = $1 AND user_date BETWEEN $2 AND $3 AND daily_kcal > 0 AND deleted_at IS NULL`)
+	mock.ExpectQuery(expectedSQL).
+		WithArgs(userID, "2024-03-01", "2024-03-30").
+		WillReturnRows(sqlmock.NewRows([]string{"average_kcal", "days"}).AddRow("2150.5", 24))
+
+	intake, err := repo.AverageIntake(userID, from, from.AddDate(0, 0, 29))
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 2150.5, intake.AverageKcal)
+	testUtil.Equal(t, 24, intake.Days)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTrend(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	entry := func(day int, kg string) *weight.Entry {
+		return &weight.Entry{EntryDate: start.AddDate(0, 0, day), WeightKg: decimal.RequireFromString(kg)}
+	}
+
+	// A gap of two days weighs the next measurement as two daily updates
+	points := weight.Smooth(weight.Entries{entry(0, "80"), entry(1, "79"), entry(3, "78")})
+	testUtil.Equal(t, 80.0, points[0].TrendKg)
+	testUtil.Equal(t, 79.9, points[1].TrendKg)
+	testUtil.Equal(t, 79.54, points[2].ToDto().TrendKg) // 79.9 + 0.19 * (78 - 79.9)
+
+	_, ok := weight.Rate(points)
+	testUtil.Equal(t, false, ok) // Less than a week of data
+
+	// Losing 0.1 kg per day over two weeks
+	entries := make(weight.Entries, 0, 15)
+	for day := 0; day <= 14; day++ {
+		entries = append(entries, entry(day, decimal.NewFromInt(80).Sub(decimal.New(int64(day), -1)).String()))
+	}
+	kgPerDay, ok := weight.Rate(weight.Smooth(entries))
+	testUtil.Equal(t, true, ok)
+	testUtil.Equal(t, -0.1, math.Round(kgPerDay*1000)/1000)
+
+	date, ok := weight.Project(start, 78.6, 75, -0.1)
+	testUtil.Equal(t, true, ok)
+	testUtil.Equal(t, start.AddDate(0, 0, 36), date)
+
+	_, ok = weight.Project(start, 78.6, 80, -0.1) // Moving away from the target
+	testUtil.Equal(t, false, ok)
+}
@@ -0,0 +1,145 @@
+package weight
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"fitapp-backend/api/resource/common/nutrient"
+)
+
+// DateFormat is the format of entry dates, matching user dates
+const DateFormat = time.DateOnly
+
+// ToDto converts an Entry model to its DTO representation
+func (e *Entry) ToDto() *DTO {
+	return &DTO{
+		ID:       e.ID.String(),
+		UserID:   e.UserID.String(),
+		Date:     e.EntryDate.Format(DateFormat),
+		WeightKg: e.WeightKg.Round(nutrient.StoragePlaces).InexactFloat64(),
+		Note:     e.Note,
+	}
+}
+
+// ToDto converts a slice of Entry models to a slice of DTOs
+func (es Entries) ToDto() []*DTO {
+	dtos := make([]*DTO, len(es))
+	for i, e := range es {
+		dtos[i] = e.ToDto()
+	}
+	return dtos
+}
+
+// Validate checks the weight range and note length and trims the note
+func (f *Form) Validate() error {
+	if f.WeightKg.LessThan(MinWeightKg) || f.WeightKg.GreaterThan(MaxWeightKg) {
+		return fmt.Errorf("weight_kg must be between %s and %s", MinWeightKg, MaxWeightKg)
+	}
+	f.Note = strings.TrimSpace(f.Note)
+	if len([]rune(f.Note)) > MaxNoteLength {
+		return fmt.Errorf("note must be at most %d characters", MaxNoteLength)
+	}
+	return nil
+}
+
+// Trend smoothing and projection parameters
+const (
+	TrendSmoothing     = 0.1 // Weight of a new daily measurement in the moving average
+	DefaultTrendWindow = 30  // Days
+	MinTrendWindow     = 7
+	MaxTrendWindow     = 365
+
+	KcalPerKg         = 7700 // Approximate energy stored in a kilogram of body weight
+	minRateSpanDays   = 7    // Measurements must span a week to derive a rate from them
+	maxProjectionDays = 3650
+)
+
+// Sources of the rate of change used for the projection
+const (
+	RateFromTrend  = "weight_trend"   // Fitted to the measurements
+	RateFromIntake = "energy_balance" // Average intake compared with estimated maintenance calories
+)
+
+// Smooth computes the exponential moving average of entries ordered by date,
+// starting at the first measurement. A gap of several days weighs the next
+// measurement as if the trend had been updated on every missing day.
+func Smooth(entries Entries) []*TrendPoint {
+	points := make([]*TrendPoint, len(entries))
+	var trend float64
+	for i, e := range entries {
+		w := e.WeightKg.InexactFloat64()
+		if i == 0 {
+			trend = w
+		} else {
+			gap := e.EntryDate.Sub(entries[i-1].EntryDate).Hours() / 24
+			alpha := 1 - math.Pow(1-TrendSmoothing, math.Max(gap, 1))
+			trend += alpha * (w - trend)
+		}
+		points[i] = &TrendPoint{Date: e.EntryDate, WeightKg: w, TrendKg: trend}
+	}
+	return points
+}
+
+// Rate fits a least squares line to the measurements and returns its slope in
+// kg per day. ok is false if the measurements span less than a week.
+func Rate(points []*TrendPoint) (kgPerDay float64, ok bool) {
+	if len(points) < 2 {
+		return 0, false
+	}
+	first := points[0].Date
+	if points[len(points)-1].Date.Sub(first).Hours()/24 < minRateSpanDays {
+		return 0, false
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	for _, p := range points {
+		x := p.Date.Sub(first).Hours() / 24
+		sumX += x
+		sumY += p.WeightKg
+		sumXY += x * p.WeightKg
+		sumXX += x * x
+	}
+	n := float64(len(points))
+	return (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX), true
+}
+
+// Project returns the date on which the weight reaches the target when
+// changing by kgPerDay from the given date. ok is false if the weight moves
+// away from the target, does not change or would take over ten years.
+func Project(from time.Time, currentKg, targetKg, kgPerDay float64) (time.Time, bool) {
+	diff := targetKg - currentKg
+	if math.Abs(diff) < 0.05 {
+		return from, true
+	}
+	if kgPerDay == 0 || (diff > 0) != (kgPerDay > 0) {
+		return time.Time{}, false
+	}
+	days := math.Ceil(diff / kgPerDay)
+	if days > maxProjectionDays {
+		return time.Time{}, false
+	}
+	return from.AddDate(0, 0, int(days)), true
+}
+
+// ToDto converts a TrendPoint to its DTO representation
+func (p *TrendPoint) ToDto() *TrendPointDTO {
+	return &TrendPointDTO{
+		Date:     p.Date.Format(DateFormat),
+		WeightKg: p.WeightKg,
+		TrendKg:  round(p.TrendKg, 2),
+	}
+}
+
+// round rounds f to the given decimal places
+func round(f float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(f*p) / p
+}
+
+// roundPtr rounds f and returns a pointer for optional DTO fields
+func roundPtr(f float64, places int) *float64 {
+	r := round(f, places)
+	return &r
+}
@@ -8,13 +8,23 @@ import (
 
 	"gorm.io/gorm"
 
+	"fitapp-backend/api/resource/auth"
+	"fitapp-backend/api/resource/common/caller"
+	"fitapp-backend/api/resource/common/token"
+	diaryentry "fitapp-backend/api/resource/diary_entry"
+	"fitapp-backend/api/resource/favorite"
+	"fitapp-backend/api/resource/goal"
 	"fitapp-backend/api/resource/health"
+	"fitapp-backend/api/resource/meal"
+	"fitapp-backend/api/resource/measurement"
 	"fitapp-backend/api/resource/product"
+	"fitapp-backend/api/resource/recipe"
 	"fitapp-backend/api/resource/user"
 	userday "fitapp-backend/api/resource/user_day"
+	"fitapp-backend/api/resource/weight"
 )
 
-func New(db *gorm.DB) *chi.Mux {
+func New(db *gorm.DB, tokens *token.Manager) *chi.Mux {
 	r := chi.NewRouter()
 
 	r.Get("/livez", health.Read)
@@ -37,26 +47,96 @@ func New(db *gorm.DB) *chi.Mux {
 	))
 
 	r.Route("/v1", func(r chi.Router) {
-		userAPI := user.New(db)
-		r.Get("/users", userAPI.List)
-		r.Post("/users", userAPI.Create)
-		r.Get("/users/{id}", userAPI.Read)
-		r.Put("/users/{id}", userAPI.Update)
-		r.Delete("/users/{id}", userAPI.Delete)
-		userdayAPI := userday.New(db)
-		r.Get("/user-days", userdayAPI.List)
-		r.Post("/user-days", userdayAPI.Create)
-		r.Get("/user-days/{id}", userdayAPI.Read)
-		r.Get("/user-days/search", userdayAPI.FindByUserAndDate)
-		r.Put("/user-days/{id}", userdayAPI.Update)
-		r.Delete("/user-days/{id}", userdayAPI.Delete)
-		productAPI := product.New(db, userdayAPI)
-		r.Get("/products", productAPI.List)
-		r.Post("/products", productAPI.Create)
-		r.Get("/products/{id}", productAPI.Read)
-		r.Put("/products/{id}", productAPI.Update)
-		r.Delete("/products/{id}", productAPI.Delete)
+		authAPI := auth.New(db, tokens)
+		r.Post("/auth/register", authAPI.Register)
+		r.Post("/auth/login", authAPI.Login)
+		r.Post("/auth/refresh", authAPI.Refresh)
 
+		// Everything else requires an access token
+		r.Group(func(r chi.Router) {
+			r.Use(caller.Authenticate(tokens, caller.CacheSessions(authAPI.SessionActive, caller.SessionCacheTTL)))
+			// Routes under /users/{id} only serve that user and admins
+			owned := r.With(caller.RequireOwner("id"))
+			r.Get("/me/sessions", authAPI.ListSessions)
+			r.Delete("/me/sessions", authAPI.RevokeAllSessions)
+			r.Delete("/me/sessions/{sessionId}", authAPI.RevokeSession)
+			r.Post("/me/2fa", authAPI.EnrollTwoFactor)
+			r.Post("/me/2fa/confirm", authAPI.ConfirmTwoFactor)
+			r.Delete("/me/2fa", authAPI.DisableTwoFactor)
+			userAPI := user.New(db)
+			r.Get("/users", userAPI.List)
+			r.Post("/users", userAPI.Create)
+			owned.Get("/users/{id}", userAPI.Read)
+			owned.Put("/users/{id}", userAPI.Update)
+			owned.Delete("/users/{id}", userAPI.Delete)
+			owned.Get("/users/{id}/energy", userAPI.Energy)
+			userdayAPI := userday.New(db)
+			r.Get("/user-days", userdayAPI.List)
+			r.Get("/user-days/{id}", userdayAPI.Read)
+			r.Get("/user-days/search", userdayAPI.FindByUserAndDate)
+			r.Delete("/user-days/{id}", userdayAPI.Delete)
+			productAPI := product.New(db)
+			r.Get("/products", productAPI.List)
+			r.Post("/products", productAPI.Create)
+			r.Get("/products/barcode/{code}", productAPI.ReadByBarcode)
+			r.Get("/products/{id}", productAPI.Read)
+			r.Put("/products/{id}", productAPI.Update)
+			r.Delete("/products/{id}", productAPI.Delete)
+			r.Get("/products/{id}/servings", productAPI.ListServings)
+			r.Post("/products/{id}/servings", productAPI.CreateServing)
+			r.Delete("/products/{id}/servings/{servingId}", productAPI.DeleteServing)
+			recipeAPI := recipe.New(db)
+			r.Get("/recipes", recipeAPI.List)
+			r.Post("/recipes", recipeAPI.Create)
+			r.Get("/recipes/{id}", recipeAPI.Read)
+			r.Put("/recipes/{id}", recipeAPI.Update)
+			r.Delete("/recipes/{id}", recipeAPI.Delete)
+			mealAPI := meal.New(db)
+			owned.Get("/users/{id}/meals", mealAPI.List)
+			owned.Post("/users/{id}/meals", mealAPI.Create)
+			owned.Delete("/users/{id}/meals/{mealId}", mealAPI.Delete)
+			diaryEntryAPI := diaryentry.New(db, userdayAPI)
+			owned.Get("/users/{id}/days/{date}/entries", diaryEntryAPI.List)
+			owned.Post("/users/{id}/days/{date}/entries", diaryEntryAPI.Create)
+			owned.Get("/users/{id}/days/{date}/entries/{entryId}", diaryEntryAPI.Read)
+			owned.Put("/users/{id}/days/{date}/entries/{entryId}", diaryEntryAPI.Update)
+			owned.Delete("/users/{id}/days/{date}/entries/{entryId}", diaryEntryAPI.Delete)
+			owned.Post("/users/{id}/days/{date}/copy", diaryEntryAPI.Copy)
+			favoriteAPI := favorite.New(db)
+			owned.Get("/users/{id}/favorites", favoriteAPI.List)
+			owned.Post("/users/{id}/favorites/{productId}", favoriteAPI.Create)
+			owned.Delete("/users/{id}/favorites/{productId}", favoriteAPI.Delete)
+			owned.Get("/users/{id}/recents", favoriteAPI.Recents)
+			goalAPI := goal.New(db)
+			owned.Get("/users/{id}/goals", goalAPI.List)
+			owned.Post("/users/{id}/goals", goalAPI.Create)
+			owned.Get("/users/{id}/goals/current", goalAPI.Current)
+			owned.Delete("/users/{id}/goals/{goalId}", goalAPI.Delete)
+			weightAPI := weight.New(db)
+			owned.Get("/users/{id}/weights", weightAPI.List)
+			owned.Post("/users/{id}/weights", weightAPI.Create)
+			owned.Get("/users/{id}/weights/{entryId}", weightAPI.Read)
+			owned.Put("/users/{id}/weights/{entryId}", weightAPI.Update)
+			owned.Delete("/users/{id}/weights/{entryId}", weightAPI.Delete)
+			owned.Get("/users/{id}/weight/trend", weightAPI.Trend)
+			measurementAPI := measurement.New(db)
+			owned.Get("/users/{id}/measurements", measurementAPI.List)
+			owned.Post("/users/{id}/measurements", measurementAPI.Create)
+			owned.Get("/users/{id}/measurements/{measurementId}", measurementAPI.Read)
+			owned.Put("/users/{id}/measurements/{measurementId}", measurementAPI.Update)
+			owned.Delete("/users/{id}/measurements/{measurementId}", measurementAPI.Delete)
+
+			// Moderation and recovery, admins only
+			r.Route("/admin", func(r chi.Router) {
+				r.Use(caller.RequireRole(caller.RoleAdmin))
+				r.Get("/users", userAPI.AdminList)
+				r.Post("/users/{id}/restore", userAPI.Restore)
+				r.Put("/users/{id}/role", userAPI.SetRole)
+				r.Put("/products/{id}/visibility", productAPI.Moderate)
+				r.Post("/products/{id}/restore", productAPI.Restore)
+				r.Post("/recipes/{id}/restore", recipeAPI.Restore)
+			})
+		})
 	})
 	return r
 }
@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	_ "time/tzdata" // Users' IANA timezones must resolve without a system zoneinfo database
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	gormlogger "gorm.io/gorm/logger"
 
+	"fitapp-backend/api/resource/common/token"
 	"fitapp-backend/api/router"
 	"fitapp-backend/config"
 )
@@ -26,6 +28,11 @@ const fmtDBString = "host=%s user=%s password=%s dbname=%s port=%d sslmode=disab
 
 // @host       localhost:8080
 // @basePath   /v1
+
+// @securityDefinitions.apikey  BearerAuth
+// @in                          header
+// @name                        Authorization
+// @description                 Access token from /auth/login, sent as "Bearer <token>"
 func main() {
 	c := config.New()
 
@@ -43,7 +50,8 @@ func main() {
 		return
 	}
 
-	r := router.New(db)
+	tokens := token.NewManager(c.Auth.JWTSecret, c.Auth.AccessTokenTTL, c.Auth.RefreshTokenTTL)
+	r := router.New(db, tokens)
 	s := &http.Server{
 		Addr:         fmt.Sprintf(":%d", c.Server.Port),
 		Handler:      r,
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"fitapp-backend/api/resource/common/nutrient"
+	"fitapp-backend/api/resource/product"
+	testUtil "fitapp-backend/util/test"
+)
+
+// complete returns a record with every required value, modified by edit
+func complete(edit func(rec *record)) *record {
+	rec := &record{
+		Code: "5901234123457",
+		Name: "Jogurt naturalny",
+		Nutriments: map[string]string{
+			offEnergyKcal:        "61",
+			"proteins_100g":      "4,3", // Some exports use a decimal comma
+			"carbohydrates_100g": "5.2",
+			"fat_100g":           "2.5",
+		},
+	}
+	if edit != nil {
+		edit(rec)
+	}
+	return rec
+}
+
+func TestToProduct(t *testing.T) {
+	t.Parallel()
+	p, err := toProduct(complete(nil))
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, "5901234123457", *p.Barcode)
+	testUtil.Equal(t, "61", p.Kcal.String())
+	testUtil.Equal(t, "4.3", p.Proteins.String())
+	testUtil.Equal(t, "5.2", p.Carbs.String())
+	testUtil.Equal(t, "2.5", p.Fats.String())
+	testUtil.Equal(t, product.VisibilityGlobal, p.Visibility)
+	testUtil.Equal(t, true, p.OwnerID == nil)
+
+	// UPC-A codes are stored in their GTIN-13 form
+	p, err = toProduct(complete(func(rec *record) { rec.Code = "036000291452" }))
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, "0036000291452", *p.Barcode)
+
+	// Names are collapsed to single spaces and capped
+	p, err = toProduct(complete(func(rec *record) { rec.Name = "  Jogurt \t naturalny " + strings.Repeat("x", 300) }))
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, maxNameLength, len([]rune(p.ProductName)))
+	testUtil.Equal(t, true, strings.HasPrefix(p.ProductName, "Jogurt naturalny x"))
+}
+
+func TestToProduct_Energy(t *testing.T) {
+	t.Parallel()
+	// Kilojoules are converted when kcal are missing
+	p, err := toProduct(complete(func(rec *record) {
+		delete(rec.Nutriments, offEnergyKcal)
+		rec.Nutriments[offEnergyKJ] = "1000"
+	}))
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, "239.01", p.Kcal.String())
+
+	// ...but kcal win when both are given
+	p, err = toProduct(complete(func(rec *record) { rec.Nutriments[offEnergyKJ] = "1000" }))
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, "61", p.Kcal.String())
+}
+
+func TestToProduct_Nutrients(t *testing.T) {
+	t.Parallel()
+	p, err := toProduct(complete(func(rec *record) {
+		rec.Nutriments["salt_100g"] = "1.25"
+		rec.Nutriments["calcium_100g"] = "0.12"   // grams, stored in milligrams
+		rec.Nutriments["vitamin-d_100g"] = "1e-6" // grams, stored in micrograms
+		rec.Nutriments["fiber_100g"] = ""         // Empty values are left out
+	}))
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, "1.25", p.Nutrients[nutrient.Salt].String())
+	testUtil.Equal(t, "500", p.Nutrients[nutrient.Sodium].String()) // Derived from salt
+	testUtil.Equal(t, "120", p.Nutrients[nutrient.Calcium].String())
+	testUtil.Equal(t, "1", p.Nutrients[nutrient.VitaminD].String())
+	_, ok := p.Nutrients[nutrient.Fiber]
+	testUtil.Equal(t, false, ok)
+}
+
+func TestToProduct_Skipped(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name string
+		edit func(rec *record)
+		want error
+	}{
+		{"missing barcode", func(rec *record) { rec.Code = "" }, product.ErrInvalidBarcode},
+		{"wrong check digit", func(rec *record) { rec.Code = "5901234123458" }, product.ErrInvalidBarcode},
+		{"missing name", func(rec *record) { rec.Name = " \t" }, errNoName},
+		{"missing energy", func(rec *record) { delete(rec.Nutriments, offEnergyKcal) }, errIncomplete},
+		{"missing macro", func(rec *record) { delete(rec.Nutriments, "fat_100g") }, errIncomplete},
+		{"negative macro", func(rec *record) { rec.Nutriments["fat_100g"] = "-1" }, errIncomplete},
+		{"unparsable macro", func(rec *record) { rec.Nutriments["fat_100g"] = "n/a" }, errIncomplete},
+		{"macro over 100 g", func(rec *record) { rec.Nutriments["proteins_100g"] = "101" }, errImplausible},
+		{"energy over 900 kcal", func(rec *record) { rec.Nutriments[offEnergyKcal] = "901" }, errImplausible},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := toProduct(complete(tc.edit))
+			testUtil.ErrorIs(t, err, tc.want)
+		})
+	}
+}
+
+// readAll drains a source, returning its records and the malformed record count
+func readAll(t *testing.T, src source) ([]*record, int) {
+	t.Helper()
+	var records []*record
+	malformed := 0
+	for {
+		rec, err := src.Next()
+		if err == io.EOF {
+			return records, malformed
+		}
+		if errors.Is(err, errMalformed) {
+			malformed++
+			continue
+		}
+		testUtil.NoError(t, err)
+		records = append(records, rec)
+	}
+}
+
+func TestJSONLSource(t *testing.T) {
+	t.Parallel()
+	input := `{"code":"5901234123457","product_name":"Jogurt","nutriments":{"energy-kcal_100g":61,"fat_100g":"2.5","fat_unit":"g","fat":2.5}}
+
+{"code":"036000291452","product_name":"","product_name_en":"Yogurt","nutriments":{"proteins_100g":4.3e0}}
+{"code": broken
+{"code":"40084107"}`
+
+	records, malformed := readAll(t, newJSONLSource(strings.NewReader(input)))
+	testUtil.Equal(t, 1, malformed)
+	testUtil.Equal(t, 3, len(records))
+
+	testUtil.Equal(t, "Jogurt", records[0].Name)
+	testUtil.Equal(t, "61", records[0].Nutriments[offEnergyKcal])
+	testUtil.Equal(t, "2.5", records[0].Nutriments["fat_100g"])
+	testUtil.Equal(t, 2, len(records[0].Nutriments)) // Only the per-100g keys
+
+	testUtil.Equal(t, "Yogurt", records[1].Name) // English name as fallback
+	testUtil.Equal(t, "4.3e0", records[1].Nutriments["proteins_100g"])
+
+	testUtil.Equal(t, "40084107", records[2].Code)
+	testUtil.Equal(t, 0, len(records[2].Nutriments))
+}
+
+func TestCSVSource(t *testing.T) {
+	t.Parallel()
+	input := "code\tproduct_name\tproduct_name_en\tenergy-kcal_100g\tfat_100g\tbrands\n" +
+		"5901234123457\t Jogurt \t\t61\t2,5\tBrand\n" +
+		"036000291452\t\tYogurt\t\t\t\n" +
+		"40084107\n" // Short rows are allowed
+
+	src, err := newCSVSource(strings.NewReader(input), '\t')
+	testUtil.NoError(t, err)
+	records, malformed := readAll(t, src)
+	testUtil.Equal(t, 0, malformed)
+	testUtil.Equal(t, 3, len(records))
+
+	testUtil.Equal(t, "Jogurt", records[0].Name)
+	testUtil.Equal(t, "61", records[0].Nutriments[offEnergyKcal])
+	testUtil.Equal(t, "2,5", records[0].Nutriments["fat_100g"])
+	testUtil.Equal(t, 2, len(records[0].Nutriments))
+
+	testUtil.Equal(t, "Yogurt", records[1].Name)
+	testUtil.Equal(t, 0, len(records[1].Nutriments)) // Empty cells are left out
+
+	testUtil.Equal(t, "40084107", records[2].Code)
+
+	_, err = newCSVSource(strings.NewReader("product_name\tfat_100g\n"), '\t')
+	testUtil.Equal(t, true, err != nil) // The code column is required
+}
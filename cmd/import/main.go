@@ -0,0 +1,183 @@
+package main
+
+import (
+	"compress/gzip"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"fitapp-backend/api/resource/product"
+	"fitapp-backend/config"
+)
+
+const fmtDBString = "host=%s user=%s password=%s dbname=%s port=%d sslmode=disable"
+
+var (
+	flags     = flag.NewFlagSet("import", flag.ExitOnError)
+	format    = flags.String("format", "", "input format: jsonl or csv (default: from file extension)")
+	batchSize = flags.Int("batch", 1000, "number of products written per transaction")
+	delimiter = flags.String("delimiter", "\t", "CSV field delimiter")
+)
+
+// stats counts the outcome of an import
+type stats struct {
+	inserted, updated, skipped int
+}
+
+func main() {
+	flags.Usage = usage
+	flags.Parse(os.Args[1:])
+
+	args := flags.Args()
+	if len(args) != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if *batchSize < 1 || *batchSize > product.MaxImportBatch {
+		log.Fatalf("batch must be between 1 and %d", product.MaxImportBatch)
+	}
+
+	path := args[0]
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer gz.Close()
+		r = gz
+		path = strings.TrimSuffix(path, ".gz")
+	}
+
+	src, err := newSource(r, path)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	c := config.NewDB()
+	dbString := fmt.Sprintf(fmtDBString, c.Host, c.Username, c.Password, c.DBName, c.Port)
+	db, err := gorm.Open(postgres.Open(dbString), &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Error)})
+	if err != nil {
+		log.Fatal("DB connection start failure")
+	}
+
+	s, err := run(src, product.NewRepository(db), *batchSize)
+	log.Printf("inserted=%d updated=%d skipped=%d", s.inserted, s.updated, s.skipped)
+	if err != nil {
+		log.Fatalf("import: %v", err)
+	}
+}
+
+// newSource picks the reader for the file format
+func newSource(r io.Reader, path string) (source, error) {
+	f := *format
+	if f == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".jsonl", ".json", ".ndjson":
+			f = "jsonl"
+		case ".csv", ".tsv":
+			f = "csv"
+		}
+	}
+
+	switch f {
+	case "jsonl":
+		return newJSONLSource(r), nil
+	case "csv":
+		d := []rune(*delimiter)
+		if len(d) != 1 {
+			return nil, errors.New("delimiter must be a single character")
+		}
+		return newCSVSource(r, d[0])
+	}
+	return nil, fmt.Errorf("unknown format %q, use -format jsonl or -format csv", f)
+}
+
+// run streams the source and writes products in batches. Only one batch is held
+// in memory; a barcode repeated within a batch keeps its last occurrence.
+func run(src source, repo *product.Repository, size int) (stats, error) {
+	var s stats
+	batch := make(product.Products, 0, size)
+	index := make(map[string]int, size) // barcode -> position in batch
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		inserted, updated, err := repo.ImportBatch(batch)
+		if err != nil {
+			return err
+		}
+		s.inserted += inserted
+		s.updated += updated
+		log.Printf("processed %d products", s.inserted+s.updated+s.skipped)
+		batch = batch[:0]
+		clear(index)
+		return nil
+	}
+
+	for {
+		rec, err := src.Next()
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, errMalformed) {
+			log.Printf("skip: %v", err)
+			s.skipped++
+			continue
+		}
+		if err != nil {
+			return s, err
+		}
+
+		p, err := toProduct(rec)
+		if err != nil {
+			s.skipped++
+			continue
+		}
+
+		if i, ok := index[*p.Barcode]; ok {
+			batch[i] = p
+			s.skipped++
+			continue
+		}
+		index[*p.Barcode] = len(batch)
+		batch = append(batch, p)
+
+		if len(batch) == size {
+			if err := flush(); err != nil {
+				return s, err
+			}
+		}
+	}
+	return s, flush()
+}
+
+func usage() {
+	fmt.Println(usageText)
+	flags.PrintDefaults()
+}
+
+var usageText = `Usage: import [flags] FILE
+Imports products from an Open Food Facts export (JSONL or tab-separated CSV,
+optionally gzip-compressed) into the global catalog keyed by barcode, updating
+catalog products already present. Products owned by users are left untouched.
+Examples:
+    import openfoodfacts-products.jsonl.gz
+    import -format csv -batch 2000 en.openfoodfacts.org.products.csv
+Flags:`
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"fitapp-backend/api/resource/common/nutrient"
+	"fitapp-backend/api/resource/product"
+)
+
+// maxNameLength caps product names, some exports contain whole ingredient lists
+const maxNameLength = 255
+
+// Open Food Facts keys of the required values
+const (
+	offEnergyKcal = "energy-kcal_100g"
+	offEnergyKJ   = "energy_100g" // kilojoules, used when kcal is missing
+)
+
+var offMacroKeys = [...]string{"proteins_100g", "carbohydrates_100g", "fat_100g"}
+
+// Reasons a record is skipped
+var (
+	errNoName      = errors.New("missing product name")
+	errIncomplete  = errors.New("missing energy or macronutrients")
+	errImplausible = errors.New("implausible nutrient values")
+)
+
+var (
+	kjPerKcal       = decimal.RequireFromString("4.184")
+	maxKcalPer100g  = decimal.NewFromInt(900)
+	maxGramsPer100g = decimal.NewFromInt(100)
+
+	gramsToGrams      = decimal.NewFromInt(1)
+	gramsToMilligrams = decimal.NewFromInt(1000)
+	gramsToMicrograms = decimal.NewFromInt(1000000)
+)
+
+// offNutrients maps Open Food Facts nutriment keys, which are all expressed in
+// grams per 100 g, to nutrient codes and the factor converting to their unit
+var offNutrients = map[string]struct {
+	code   string
+	factor decimal.Decimal
+}{
+	"fiber_100g":         {nutrient.Fiber, gramsToGrams},
+	"sugars_100g":        {nutrient.Sugars, gramsToGrams},
+	"saturated-fat_100g": {nutrient.SaturatedFat, gramsToGrams},
+	"trans-fat_100g":     {nutrient.TransFat, gramsToGrams},
+	"salt_100g":          {nutrient.Salt, gramsToGrams},
+	"sodium_100g":        {nutrient.Sodium, gramsToMilligrams},
+	"cholesterol_100g":   {nutrient.Cholesterol, gramsToMilligrams},
+	"potassium_100g":     {nutrient.Potassium, gramsToMilligrams},
+	"calcium_100g":       {nutrient.Calcium, gramsToMilligrams},
+	"iron_100g":          {nutrient.Iron, gramsToMilligrams},
+	"magnesium_100g":     {nutrient.Magnesium, gramsToMilligrams},
+	"vitamin-a_100g":     {nutrient.VitaminA, gramsToMicrograms},
+	"vitamin-c_100g":     {nutrient.VitaminC, gramsToMilligrams},
+	"vitamin-d_100g":     {nutrient.VitaminD, gramsToMicrograms},
+	"vitamin-b12_100g":   {nutrient.VitaminB12, gramsToMicrograms},
+}
+
+// toProduct maps a record to a new product with per-100g values.
+// Records without a valid barcode, name or complete macros are rejected.
+func toProduct(rec *record) (*product.Product, error) {
+	barcode, err := product.NormalizeBarcode(rec.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.Join(strings.Fields(rec.Name), " ")
+	if name == "" {
+		return nil, errNoName
+	}
+	if r := []rune(name); len(r) > maxNameLength {
+		name = string(r[:maxNameLength])
+	}
+
+	kcal, ok := value(rec, offEnergyKcal)
+	if !ok {
+		kj, ok := value(rec, offEnergyKJ)
+		if !ok {
+			return nil, errIncomplete
+		}
+		kcal = kj.Div(kjPerKcal)
+	}
+	var macros [len(offMacroKeys)]decimal.Decimal
+	for i, key := range offMacroKeys {
+		v, ok := value(rec, key)
+		if !ok {
+			return nil, errIncomplete
+		}
+		if v.GreaterThan(maxGramsPer100g) {
+			return nil, errImplausible
+		}
+		macros[i] = v
+	}
+	if kcal.GreaterThan(maxKcalPer100g) {
+		return nil, errImplausible
+	}
+
+	nutrients := nutrient.Set{}
+	for key, n := range offNutrients {
+		if v, ok := value(rec, key); ok {
+			nutrients[n.code] = nutrient.Round(v.Mul(n.factor))
+		}
+	}
+
+	return &product.Product{
+		ID:          uuid.New(),
+		ProductName: name,
+		Kcal:        nutrient.Round(kcal),
+		Proteins:    nutrient.Round(macros[0]),
+		Carbs:       nutrient.Round(macros[1]),
+		Fats:        nutrient.Round(macros[2]),
+		Barcode:     &barcode,
+		Nutrients:   nutrients.Complete(),
+		Visibility:  product.VisibilityGlobal,
+	}, nil
+}
+
+// value parses a non-negative nutriment value of the record
+func value(rec *record, key string) (decimal.Decimal, bool) {
+	raw, ok := rec.Nutriments[key]
+	if !ok || raw == "" {
+		return decimal.Zero, false
+	}
+	v, err := decimal.NewFromString(strings.Replace(raw, ",", ".", 1))
+	if err != nil || v.IsNegative() {
+		return decimal.Zero, false
+	}
+	return v, true
+}
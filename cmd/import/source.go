@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// errMalformed marks a record that could not be parsed; the import skips it and continues
+var errMalformed = errors.New("malformed record")
+
+// record is a single Open Food Facts product reduced to the fields the importer uses
+type record struct {
+	Code       string
+	Name       string
+	Nutriments map[string]string // "<nutrient>_100g" keys as in the export, raw values
+}
+
+// source streams records from an export file. Next returns io.EOF when the
+// file is exhausted and an error wrapping errMalformed for unreadable records.
+type source interface {
+	Next() (*record, error)
+}
+
+// jsonlSource reads the JSONL export, one product object per line
+type jsonlSource struct {
+	r    *bufio.Reader
+	line int
+}
+
+func newJSONLSource(r io.Reader) *jsonlSource {
+	return &jsonlSource{r: bufio.NewReaderSize(r, 1<<20)}
+}
+
+// offProduct is the subset of an Open Food Facts JSON product we decode
+type offProduct struct {
+	Code          string                 `json:"code"`
+	ProductName   string                 `json:"product_name"`
+	ProductNameEn string                 `json:"product_name_en"`
+	Nutriments    map[string]interface{} `json:"nutriments"`
+}
+
+func (s *jsonlSource) Next() (*record, error) {
+	for {
+		// ReadBytes has no line length limit, unlike bufio.Scanner
+		line, err := s.r.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			return nil, err // io.EOF or a read error
+		}
+		s.line++
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		var p offProduct
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.UseNumber()
+		if err := decoder.Decode(&p); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", errMalformed, s.line, err)
+		}
+
+		rec := &record{Code: p.Code, Name: p.ProductName, Nutriments: make(map[string]string, len(p.Nutriments))}
+		if rec.Name == "" {
+			rec.Name = p.ProductNameEn
+		}
+		for key, value := range p.Nutriments {
+			if !strings.HasSuffix(key, "_100g") {
+				continue
+			}
+			switch v := value.(type) {
+			case json.Number:
+				rec.Nutriments[key] = v.String()
+			case string:
+				rec.Nutriments[key] = v
+			}
+		}
+		return rec, nil
+	}
+}
+
+// csvSource reads the CSV export, which Open Food Facts publishes tab-separated
+type csvSource struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+func newCSVSource(r io.Reader, delimiter rune) (*csvSource, error) {
+	cr := csv.NewReader(bufio.NewReaderSize(r, 1<<20))
+	cr.Comma = delimiter
+	cr.LazyQuotes = true
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	if _, ok := columns["code"]; !ok {
+		return nil, errors.New("header has no 'code' column")
+	}
+	return &csvSource{r: cr, columns: columns}, nil
+}
+
+func (s *csvSource) Next() (*record, error) {
+	fields, err := s.r.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, fmt.Errorf("%w: %v", errMalformed, err)
+		}
+		return nil, err // io.EOF or a read error
+	}
+
+	field := func(name string) string {
+		if i, ok := s.columns[name]; ok && i < len(fields) {
+			return strings.TrimSpace(fields[i])
+		}
+		return ""
+	}
+
+	rec := &record{Code: field("code"), Name: field("product_name"), Nutriments: make(map[string]string)}
+	if rec.Name == "" {
+		rec.Name = field("product_name_en")
+	}
+	for name := range s.columns {
+		if strings.HasSuffix(name, "_100g") {
+			if v := field(name); v != "" {
+				rec.Nutriments[name] = v
+			}
+		}
+	}
+	return rec, nil
+}
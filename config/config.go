@@ -11,6 +11,7 @@ import (
 type Conf struct {
 	Server ConfServer
 	DB     ConfDB
+	Auth   ConfAuth
 }
 
 type ConfServer struct {
@@ -30,6 +31,16 @@ type ConfDB struct {
 	Debug    bool   `env:"DB_DEBUG,required"`
 }
 
+// MinJWTSecretLength is the shortest accepted token signing secret in bytes,
+// the output size of the HS256 hash
+const MinJWTSecretLength = 32
+
+type ConfAuth struct {
+	JWTSecret       string        `env:"AUTH_JWT_SECRET,required"`
+	AccessTokenTTL  time.Duration `env:"AUTH_ACCESS_TOKEN_TTL,default=15m"`
+	RefreshTokenTTL time.Duration `env:"AUTH_REFRESH_TOKEN_TTL,default=720h"`
+}
+
 func init() {
 	// Załaduj zmienne środowiskowe z pliku .env
 	if err := godotenv.Load(); err != nil {
@@ -42,6 +53,9 @@ func New() *Conf {
 	if err := envdecode.StrictDecode(&c); err != nil {
 		log.Fatalf("Failed to decode: %s", err)
 	}
+	if len(c.Auth.JWTSecret) < MinJWTSecretLength {
+		log.Fatalf("AUTH_JWT_SECRET must be at least %d bytes long", MinJWTSecretLength)
+	}
 	return &c
 }
 
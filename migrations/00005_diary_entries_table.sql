@@ -0,0 +1,27 @@
+-- +goose Up
+-- From here on the totals of user_days are derived from their diary entries.
+-- Legacy totals cannot be turned into entries, as nothing records the products
+-- they came from: they stay readable until an entry is written on that day,
+-- which recomputes the totals from the entries and discards the legacy values.
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS diary_entries
+(
+    user_id UUID NOT NULL REFERENCES users(id),
+    user_date  DATE NOT NULL,
+    product_id UUID NOT NULL REFERENCES products(id),
+    grams INTEGER NOT NULL,
+    kcal INTEGER NOT NULL,
+    proteins INTEGER NOT NULL,
+    carbs INTEGER NOT NULL,
+    fats INTEGER NOT NULL,
+    logged_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (id)  -- Explicitly define primary key
+) INHERITS (base_entity);
+
+CREATE INDEX IF NOT EXISTS idx_diary_entry_user_date ON diary_entries (user_id, user_date);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS diary_entries;
+-- +goose StatementEnd
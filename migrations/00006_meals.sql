@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE diary_entries ADD COLUMN IF NOT EXISTS meal TEXT NOT NULL DEFAULT 'snacks';
+
+CREATE TABLE IF NOT EXISTS custom_meals
+(
+    user_id UUID NOT NULL REFERENCES users(id),
+    meal_name TEXT NOT NULL,
+    PRIMARY KEY (id)  -- Explicitly define primary key
+) INHERITS (base_entity);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_custom_meal_user_name ON custom_meals (user_id, meal_name) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS custom_meals;
+ALTER TABLE diary_entries DROP COLUMN IF EXISTS meal;
+-- +goose StatementEnd
@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Merge duplicated days created by concurrent requests into the oldest record
+UPDATE user_days AS keep
+SET daily_kcal     = dup.daily_kcal,
+    daily_proteins = dup.daily_proteins,
+    daily_carbs    = dup.daily_carbs,
+    daily_fats     = dup.daily_fats,
+    updated_at     = NOW()
+FROM (
+    SELECT user_id, user_date,
+           SUM(COALESCE(daily_kcal, 0))     AS daily_kcal,
+           SUM(COALESCE(daily_proteins, 0)) AS daily_proteins,
+           SUM(COALESCE(daily_carbs, 0))    AS daily_carbs,
+           SUM(COALESCE(daily_fats, 0))     AS daily_fats,
+           (ARRAY_AGG(id ORDER BY created_at, id))[1] AS keep_id
+    FROM user_days
+    WHERE deleted_at IS NULL
+    GROUP BY user_id, user_date
+    HAVING COUNT(*) > 1
+) AS dup
+WHERE keep.id = dup.keep_id;
+
+UPDATE user_days AS ud
+SET deleted_at = NOW()
+WHERE ud.deleted_at IS NULL
+  AND EXISTS (
+    SELECT 1 FROM user_days AS older
+    WHERE older.deleted_at IS NULL
+      AND older.user_id = ud.user_id
+      AND older.user_date = ud.user_date
+      AND (older.created_at, older.id) < (ud.created_at, ud.id)
+  );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_userday_user_date_unique ON user_days (user_id, user_date) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_userday_user_date_unique;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS recipes
+(
+    user_id UUID NOT NULL REFERENCES users(id),
+    recipe_name TEXT NOT NULL,
+    servings INTEGER NOT NULL,
+    cooked_weight INTEGER NULL, -- grams after cooking; raw ingredient weight is used when NULL
+    PRIMARY KEY (id)  -- Explicitly define primary key
+) INHERITS (base_entity);
+
+CREATE TABLE IF NOT EXISTS recipe_ingredients
+(
+    recipe_id UUID NOT NULL REFERENCES recipes(id),
+    product_id UUID NOT NULL REFERENCES products(id),
+    grams INTEGER NOT NULL,
+    PRIMARY KEY (id)  -- Explicitly define primary key
+) INHERITS (base_entity);
+
+CREATE INDEX IF NOT EXISTS idx_recipe_ingredient_recipe ON recipe_ingredients (recipe_id);
+
+ALTER TABLE diary_entries ALTER COLUMN product_id DROP NOT NULL;
+ALTER TABLE diary_entries ADD COLUMN IF NOT EXISTS recipe_id UUID NULL REFERENCES recipes(id);
+ALTER TABLE diary_entries ADD CONSTRAINT chk_diary_entry_source CHECK ((product_id IS NULL) <> (recipe_id IS NULL));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Recipe entries have no product to fall back on and cannot survive the NOT NULL below
+DELETE FROM diary_entries WHERE product_id IS NULL;
+ALTER TABLE diary_entries DROP CONSTRAINT IF EXISTS chk_diary_entry_source;
+ALTER TABLE diary_entries DROP COLUMN IF EXISTS recipe_id;
+ALTER TABLE diary_entries ALTER COLUMN product_id SET NOT NULL;
+DROP TABLE IF EXISTS recipe_ingredients;
+DROP TABLE IF EXISTS recipes;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE products ADD COLUMN IF NOT EXISTS density DOUBLE PRECISION NULL; -- grams per millilitre
+
+CREATE TABLE IF NOT EXISTS product_servings
+(
+    product_id UUID NOT NULL REFERENCES products(id),
+    serving_name TEXT NOT NULL,
+    amount DOUBLE PRECISION NOT NULL,
+    unit TEXT NOT NULL, -- 'g' or 'ml'
+    PRIMARY KEY (id)  -- Explicitly define primary key
+) INHERITS (base_entity);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_serving_name ON product_servings (product_id, serving_name) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS product_servings;
+ALTER TABLE products DROP COLUMN IF EXISTS density;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE products ADD COLUMN IF NOT EXISTS barcode TEXT NULL; -- normalized to 13 digits (GTIN-13)
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_barcode ON products (barcode) WHERE barcode IS NOT NULL AND deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_product_barcode;
+ALTER TABLE products DROP COLUMN IF EXISTS barcode;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- unaccent() is only STABLE; an IMMUTABLE wrapper is required to use it in an index
+CREATE OR REPLACE FUNCTION f_unaccent(TEXT) RETURNS TEXT
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT AS
+$$ SELECT public.unaccent('public.unaccent', $1) $$;
+
+CREATE INDEX IF NOT EXISTS idx_product_name_trgm ON products USING GIN (f_unaccent(lower(product_name)) gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_product_name_trgm;
+DROP FUNCTION IF EXISTS f_unaccent(TEXT);
+-- +goose StatementEnd
@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE products
+    ALTER COLUMN kcal TYPE NUMERIC(10,2),
+    ALTER COLUMN proteins TYPE NUMERIC(10,2),
+    ALTER COLUMN carbs TYPE NUMERIC(10,2),
+    ALTER COLUMN fats TYPE NUMERIC(10,2);
+
+ALTER TABLE diary_entries
+    ALTER COLUMN kcal TYPE NUMERIC(10,2),
+    ALTER COLUMN proteins TYPE NUMERIC(10,2),
+    ALTER COLUMN carbs TYPE NUMERIC(10,2),
+    ALTER COLUMN fats TYPE NUMERIC(10,2);
+
+ALTER TABLE user_days
+    ALTER COLUMN daily_kcal TYPE NUMERIC(10,2),
+    ALTER COLUMN daily_proteins TYPE NUMERIC(10,2),
+    ALTER COLUMN daily_carbs TYPE NUMERIC(10,2),
+    ALTER COLUMN daily_fats TYPE NUMERIC(10,2);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_days
+    ALTER COLUMN daily_kcal TYPE INTEGER USING ROUND(daily_kcal),
+    ALTER COLUMN daily_proteins TYPE INTEGER USING ROUND(daily_proteins),
+    ALTER COLUMN daily_carbs TYPE INTEGER USING ROUND(daily_carbs),
+    ALTER COLUMN daily_fats TYPE INTEGER USING ROUND(daily_fats);
+
+ALTER TABLE diary_entries
+    ALTER COLUMN kcal TYPE INTEGER USING ROUND(kcal),
+    ALTER COLUMN proteins TYPE INTEGER USING ROUND(proteins),
+    ALTER COLUMN carbs TYPE INTEGER USING ROUND(carbs),
+    ALTER COLUMN fats TYPE INTEGER USING ROUND(fats);
+
+ALTER TABLE products
+    ALTER COLUMN kcal TYPE INTEGER USING ROUND(kcal),
+    ALTER COLUMN proteins TYPE INTEGER USING ROUND(proteins),
+    ALTER COLUMN carbs TYPE INTEGER USING ROUND(carbs),
+    ALTER COLUMN fats TYPE INTEGER USING ROUND(fats);
+-- +goose StatementEnd
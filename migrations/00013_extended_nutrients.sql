@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Extended nutrient profile stored as {"<code>": amount}, codes defined in nutrient.Catalog
+ALTER TABLE products ADD COLUMN IF NOT EXISTS nutrients JSONB NOT NULL DEFAULT '{}'; -- per 100 g
+ALTER TABLE diary_entries ADD COLUMN IF NOT EXISTS nutrients JSONB NOT NULL DEFAULT '{}'; -- snapshot scaled to grams
+ALTER TABLE user_days ADD COLUMN IF NOT EXISTS nutrients JSONB NOT NULL DEFAULT '{}'; -- day totals
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_days DROP COLUMN IF EXISTS nutrients;
+ALTER TABLE diary_entries DROP COLUMN IF EXISTS nutrients;
+ALTER TABLE products DROP COLUMN IF EXISTS nutrients;
+-- +goose StatementEnd
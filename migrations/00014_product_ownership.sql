@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE products ADD COLUMN IF NOT EXISTS owner_id UUID NULL REFERENCES users(id); -- NULL for catalog products
+ALTER TABLE products ADD COLUMN IF NOT EXISTS visibility TEXT NOT NULL DEFAULT 'global'; -- existing products form the verified catalog
+ALTER TABLE products ADD CONSTRAINT chk_product_visibility CHECK (visibility IN ('private', 'shared', 'global'));
+ALTER TABLE products ADD CONSTRAINT chk_product_owner CHECK (owner_id IS NOT NULL OR visibility = 'global');
+
+CREATE INDEX IF NOT EXISTS idx_products_owner_id ON products (owner_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_products_owner_id;
+ALTER TABLE products DROP CONSTRAINT IF EXISTS chk_product_owner;
+ALTER TABLE products DROP CONSTRAINT IF EXISTS chk_product_visibility;
+ALTER TABLE products DROP COLUMN IF EXISTS visibility;
+ALTER TABLE products DROP COLUMN IF EXISTS owner_id;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS favorite_products
+(
+    user_id UUID NOT NULL REFERENCES users(id),
+    product_id UUID NOT NULL REFERENCES products(id),
+    PRIMARY KEY (id)  -- Explicitly define primary key
+) INHERITS (base_entity);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_favorite_product_user_product ON favorite_products (user_id, product_id) WHERE deleted_at IS NULL;
+
+-- Recently logged products are aggregated from a user's latest diary entries
+CREATE INDEX IF NOT EXISTS idx_diary_entry_user_logged_at ON diary_entries (user_id, logged_at) WHERE product_id IS NOT NULL AND deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_diary_entry_user_logged_at;
+DROP TABLE IF EXISTS favorite_products;
+-- +goose StatementEnd
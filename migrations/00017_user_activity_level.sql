@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN IF NOT EXISTS user_activity_level TEXT NOT NULL DEFAULT 'sedentary';
+ALTER TABLE users ADD CONSTRAINT chk_user_activity_level
+    CHECK (user_activity_level IN ('sedentary', 'light', 'moderate', 'active', 'very_active'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_user_activity_level;
+ALTER TABLE users DROP COLUMN IF EXISTS user_activity_level;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS goals
+(
+    user_id UUID NOT NULL REFERENCES users(id),
+    effective_from DATE NOT NULL,
+    basis TEXT NOT NULL DEFAULT 'absolute',
+    kcal NUMERIC(10,2) NOT NULL,
+    proteins NUMERIC(10,2) NOT NULL,
+    carbs NUMERIC(10,2) NOT NULL,
+    fats NUMERIC(10,2) NOT NULL,
+    PRIMARY KEY (id),  -- Explicitly define primary key
+    CONSTRAINT chk_goal_basis CHECK (basis IN ('absolute', 'per_kg'))
+) INHERITS (base_entity);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_goal_user_effective_from ON goals (user_id, effective_from) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS goals;
+-- +goose StatementEnd
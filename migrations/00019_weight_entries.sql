@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS weight_entries
+(
+    user_id UUID NOT NULL REFERENCES users(id),
+    entry_date DATE NOT NULL,
+    weight_kg NUMERIC(5,2) NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (id)  -- Explicitly define primary key
+) INHERITS (base_entity);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_weight_entry_user_date ON weight_entries (user_id, entry_date) WHERE deleted_at IS NULL;
+
+-- Start every history with the profile weight, dated when the profile was last updated
+INSERT INTO weight_entries (id, created_at, updated_at, user_id, entry_date, weight_kg, note)
+SELECT gen_random_uuid(), NOW(), NOW(), id, updated_at::date, user_weight, ''
+FROM users
+WHERE deleted_at IS NULL AND user_weight > 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS weight_entries;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE goals ADD COLUMN IF NOT EXISTS target_weight_kg NUMERIC(5,2) NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE goals DROP COLUMN IF EXISTS target_weight_kg;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS body_measurements
+(
+    user_id UUID NOT NULL REFERENCES users(id),
+    measured_on DATE NOT NULL,
+    waist_cm NUMERIC(5,1) NULL,
+    neck_cm NUMERIC(5,1) NULL,
+    hips_cm NUMERIC(5,1) NULL,
+    chest_cm NUMERIC(5,1) NULL,
+    arm_cm NUMERIC(5,1) NULL,
+    thigh_cm NUMERIC(5,1) NULL,
+    PRIMARY KEY (id)  -- Explicitly define primary key
+) INHERITS (base_entity);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_body_measurement_user_date ON body_measurements (user_id, measured_on) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS body_measurements;
+-- +goose StatementEnd
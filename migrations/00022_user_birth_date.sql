@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN IF NOT EXISTS user_birth_date DATE NULL;
+-- The stored age was entered at some point in the year of life; assume the middle of it
+UPDATE users SET user_birth_date = (CURRENT_DATE - make_interval(years => user_age, months => 6))::date;
+ALTER TABLE users ALTER COLUMN user_birth_date SET NOT NULL;
+ALTER TABLE users DROP COLUMN IF EXISTS user_age;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN IF NOT EXISTS user_age INTEGER NULL;
+UPDATE users SET user_age = date_part('year', age(CURRENT_DATE, user_birth_date))::integer;
+ALTER TABLE users ALTER COLUMN user_age SET NOT NULL;
+ALTER TABLE users DROP COLUMN IF EXISTS user_birth_date;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Users created before registration existed have no password and cannot log in
+ALTER TABLE users ADD COLUMN IF NOT EXISTS user_password_hash TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_username ON users (user_username) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_user_username;
+ALTER TABLE users DROP COLUMN IF EXISTS user_password_hash;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The first admin is granted directly: UPDATE users SET user_role = 'admin' WHERE user_username = '...';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS user_role TEXT NOT NULL DEFAULT 'user';
+ALTER TABLE users ADD CONSTRAINT chk_user_role CHECK (user_role IN ('user', 'admin'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_user_role;
+ALTER TABLE users DROP COLUMN IF EXISTS user_role;
+-- +goose StatementEnd
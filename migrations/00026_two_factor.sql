@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS two_factors
+(
+    user_id UUID NOT NULL REFERENCES users(id),
+    secret TEXT NOT NULL,
+    enabled_at TIMESTAMP NULL,
+    last_step BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (id)  -- Explicitly define primary key
+) INHERITS (base_entity);
+
+-- One enrolment per user, pending or enabled
+CREATE UNIQUE INDEX IF NOT EXISTS idx_two_factor_user ON two_factors (user_id) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS recovery_codes
+(
+    user_id UUID NOT NULL REFERENCES users(id),
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMP NULL,
+    PRIMARY KEY (id)  -- Explicitly define primary key
+) INHERITS (base_entity);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_code_user ON recovery_codes (user_id) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS two_factors;
+-- +goose StatementEnd
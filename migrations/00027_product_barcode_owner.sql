@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_product_barcode;
+-- Barcodes are unique per owner, and separately within the ownerless catalog
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_owner_barcode ON products (owner_id, barcode) WHERE barcode IS NOT NULL AND owner_id IS NOT NULL AND deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_catalog_barcode ON products (barcode) WHERE barcode IS NOT NULL AND owner_id IS NULL AND deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_product_catalog_barcode;
+DROP INDEX IF EXISTS idx_product_owner_barcode;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_barcode ON products (barcode) WHERE barcode IS NOT NULL AND deleted_at IS NULL;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE two_factors ADD COLUMN IF NOT EXISTS failed_attempts INT NOT NULL DEFAULT 0; -- consecutive invalid codes
+ALTER TABLE two_factors ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE two_factors DROP COLUMN IF EXISTS locked_until;
+ALTER TABLE two_factors DROP COLUMN IF EXISTS failed_attempts;
+-- +goose StatementEnd
@@ -1,6 +1,8 @@
 package test
 
 import (
+	"errors"
+	"reflect"
 	"testing"
 )
 
@@ -15,3 +17,22 @@ func Equal[T comparable](t *testing.T, x, y T) {
 		t.Fatalf("not equal: %v, %v", x, y)
 	}
 }
+
+func NotNil(t *testing.T, v interface{}) {
+	if v == nil {
+		t.Fatalf("unexpected nil")
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		if rv.IsNil() {
+			t.Fatalf("unexpected nil")
+		}
+	}
+}
+
+func ErrorIs(t *testing.T, err, target error) {
+	if !errors.Is(err, target) {
+		t.Fatalf("error mismatch: got %v, want %v", err, target)
+	}
+}